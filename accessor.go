@@ -0,0 +1,171 @@
+package geaves
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// dataTypes are the Go types values of each attribute type are held in, see Value
+var dataTypes = map[AttributeType]reflect.Type{
+	BoolType: reflect.TypeFor[bool](),
+	StringType: reflect.TypeFor[string](),
+	IntType: reflect.TypeFor[int](),
+	Int8Type: reflect.TypeFor[int8](),
+	Int16Type: reflect.TypeFor[int16](),
+	Int32Type: reflect.TypeFor[int32](),
+	Int64Type: reflect.TypeFor[int64](),
+	UintType: reflect.TypeFor[uint](),
+	Uint8Type: reflect.TypeFor[uint8](),
+	Uint16Type: reflect.TypeFor[uint16](),
+	Uint32Type: reflect.TypeFor[uint32](),
+	Uint64Type: reflect.TypeFor[uint64](),
+	ByteType: reflect.TypeFor[byte](),
+	RuneType: reflect.TypeFor[rune](),
+	Float32Type: reflect.TypeFor[float32](),
+	Float64Type: reflect.TypeFor[float64](),
+	BlobType: reflect.TypeFor[[]byte](),
+	DateType: reflect.TypeFor[time.Time](),
+	TimeType: reflect.TypeFor[time.Time](),
+	DatetimeType: reflect.TypeFor[time.Time](),
+	DecimalType: reflect.TypeFor[Decimal](),
+	MoneyType: reflect.TypeFor[Money](),
+}
+
+// isInteger reports whether values of type t are Go integers, which encodeValue takes of any width
+func isInteger(t AttributeType) bool {
+	_, ok := integerRanges[t]
+	return ok
+}
+
+// checkGoType returns an ErrInvalidType ValidationError unless values of attribute can be held in typ,
+// which is its Go type or an interface it implements, integers may also be set from any Go integer type
+func checkGoType(attribute Attribute, typ reflect.Type, setting bool) error {
+	data, ok := dataTypes[attribute.Type.Base()]
+	if !ok {
+		return &ValidationError{Field: attribute.Slug, Value: attribute.Type, Err: ErrInvalidType}
+	}
+
+	switch {
+	case typ == data:
+		return nil
+	case typ.Kind() == reflect.Interface && data.Implements(typ):
+		return nil
+	case setting && isInteger(attribute.Type) && typ.PkgPath() == "" && typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		return nil
+	}
+
+	return &ValidationError{
+		Field: attribute.Slug,
+		Value: typ,
+		Err: fmt.Errorf("%w: %s values are %s", ErrInvalidType, attribute.Type, data),
+	}
+}
+
+// itemAttribute returns the attribute with slug linked to the entity of the item with itemID,
+// an ErrNotFound ValidationError when the item has no such attribute
+func itemAttribute(ctx context.Context, q *Queries, itemID int64, slug string) (EntityAttributeEmbed, error) {
+	item, err := q.GetItem(ctx, itemID)
+	if err != nil {
+		return EntityAttributeEmbed{}, fmt.Errorf("Failed to get item %v: %w", itemID, err)
+	}
+
+	entity, err := q.GetEntity(ctx, GetEntityParam{WithAttributes: true, Field: ByID, Value: item.EntityID})
+	if err != nil {
+		return EntityAttributeEmbed{}, fmt.Errorf("Failed to get entity of item %v: %w", itemID, err)
+	}
+
+	attribute, ok := entity.attribute(slug)
+	if !ok {
+		return attribute, &ValidationError{Field: slug, Err: fmt.Errorf("%w: not linked to %s", ErrNotFound, entity.Slug)}
+	}
+
+	return attribute, nil
+}
+
+const getItemValue = `
+SELECT value FROM item_attribute WHERE item_id = ? AND attribute_id = ? AND item_id IN (SELECT id FROM items WHERE tenant_id = ?);
+`
+
+// Get reads the value an item has for the attribute with slug attrSlug, ok is false when it has none,
+// T must be the Go type of the attribute's type (see Value) or an interface it implements, like any.
+// A missing item or an attribute not linked to its entity is ErrNotFound
+func Get[T any](ctx context.Context, q *Queries, itemID int64, attrSlug string) (T, bool, error) {
+	var zero T
+
+	attribute, err := itemAttribute(ctx, q, itemID, attrSlug)
+	if err != nil {
+		return zero, false, err
+	}
+
+	if err := checkGoType(attribute.Attribute, reflect.TypeFor[T](), false); err != nil {
+		return zero, false, err
+	}
+
+	var raw any
+	err = q.queryRowContext(ctx, getItemValue, itemID, attribute.ID, q.tenant).Scan(&raw)
+	if err != nil {
+		err = classify(err)
+		if errors.Is(err, ErrNotFound) {
+			return zero, false, nil
+		}
+		return zero, false, err
+	}
+
+	value, err := decodeValue(attribute.Type, raw)
+	if err != nil {
+		return zero, false, fmt.Errorf("Failed to read %s on item %v: %w", attrSlug, itemID, err)
+	}
+
+	if value.IsNull() {
+		return zero, false, nil
+	}
+
+	return value.Data.(T), true, nil
+}
+
+// Set stores v as the value an item has for the attribute with slug attrSlug, whether or not it already has one,
+// T must be the Go type of the attribute's type (see Value), any Go integer type in range for integer types,
+// or an interface holding such a value, the attribute must be linked to the entity of the item like for Item.Save
+func Set[T any](ctx context.Context, q *Queries, itemID int64, attrSlug string, v T) error {
+	return q.RunInTx(ctx, func(q *Queries) error {
+		attribute, err := itemAttribute(ctx, q, itemID, attrSlug)
+		if err != nil {
+			return err
+		}
+
+		if err := checkGoType(attribute.Attribute, reflect.TypeFor[T](), true); err != nil {
+			return err
+		}
+
+		if err := q.SetItemValue(ctx, itemID, attribute.ID, Value{Type: attribute.Type, Data: any(v)}); err != nil {
+			return fmt.Errorf("Failed to set %s on item %v: %w", attrSlug, itemID, err)
+		}
+
+		return nil
+	})
+}
+
+// Unset removes the value an item has for the attribute with slug attrSlug, a value that was never set is already unset,
+// the value of a required attribute is not removed like for Item.Save
+func Unset(ctx context.Context, q *Queries, itemID int64, attrSlug string) error {
+	return q.RunInTx(ctx, func(q *Queries) error {
+		attribute, err := itemAttribute(ctx, q, itemID, attrSlug)
+		if err != nil {
+			return err
+		}
+
+		if attribute.Required {
+			return &ValidationError{Field: attribute.Slug, Err: ErrRequiredMissing}
+		}
+
+		err = q.DeleteItemAttributes(ctx, itemID, attribute.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("Failed to unset %s on item %v: %w", attrSlug, itemID, err)
+		}
+
+		return nil
+	})
+}
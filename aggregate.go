@@ -0,0 +1,326 @@
+package geaves
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type AggregateFunc string
+const (
+	AggCount AggregateFunc = "count"
+	AggCountDistinct AggregateFunc = "count-distinct"
+	AggSum AggregateFunc = "sum"
+	AggAvg AggregateFunc = "avg"
+	AggMin AggregateFunc = "min"
+	AggMax AggregateFunc = "max"
+)
+
+func ValidAggregateFunc(f string) bool {
+	v := AggregateFunc(f)
+	return AggCount == v ||
+		AggCountDistinct == v ||
+		AggSum == v ||
+		AggAvg == v ||
+		AggMin == v ||
+		AggMax == v
+}
+
+// AggregateQuery describes Func applied over the values of Attribute (a slug) on items of EntityID,
+// Attribute may be left empty for AggCount to count items, rows are grouped by the values of the
+// GroupBy attribute slugs and only items matching all of Where are considered.
+// Sums and averages of decimal and money are exact and of the type of Attribute, averages rounded half away
+// from zero, aggregating money in more than one currency fails with ErrMixedCurrencies.
+// Attribute and GroupBy must be linked to the entity, others fail with an ErrNotFound ValidationError
+type AggregateQuery struct {
+	EntityID int64
+	Func AggregateFunc
+	Attribute string
+	GroupBy []string
+	Where []Predicate
+}
+
+type AggregateRow struct {
+	Group []Value
+	Value Value
+}
+
+// uintSumExpr sums the high and low 10 digits of uint and uint64 values apart, their sum does not fit the integers
+// sqlite sums in, the halves are added up in uintSum. Values stored as integers before are padded like the others
+const uintSumExpr = `SUM(CAST(substr(CASE typeof(agg.value) WHEN 'integer' THEN printf('u%020d', agg.value) ELSE agg.value END, 2, 10) AS INTEGER)), ` +
+	`SUM(CAST(substr(CASE typeof(agg.value) WHEN 'integer' THEN printf('u%020d', agg.value) ELSE agg.value END, 12) AS INTEGER)), ` +
+	`COUNT(agg.value)`
+
+// uintSum adds up the halves summed by uintSumExpr into the exact sum as uint64, or the average as float64
+func uintSum(f AggregateFunc, raw []any) (Value, error) {
+	var parts [3]int64
+	for idx := range parts {
+		if raw[idx] == nil {
+			continue
+		}
+
+		i, ok := raw[idx].(int64)
+		if !ok {
+			return Value{}, fmt.Errorf("stored %T can not be summed as %s", raw[idx], Uint64Type)
+		}
+		parts[idx] = i
+	}
+
+	high, low, count := parts[0], parts[1], parts[2]
+	if f == AggAvg {
+		if count == 0 {
+			return Value{Type: Float64Type}, nil
+		}
+
+		return Value{Float64Type, (float64(high) * 1e10 + float64(low)) / float64(count)}, nil
+	}
+
+	if count == 0 {
+		return Value{Type: Uint64Type}, nil
+	}
+
+	// Carry what the low digits sum to above 10 digits before the high digits are scaled up
+	high += low / 1e10
+	low %= 1e10
+	if uint64(high) > (math.MaxUint64 - uint64(low)) / 1e10 {
+		return Value{}, fmt.Errorf("%s overflows %s", f, Uint64Type)
+	}
+
+	return Value{Uint64Type, uint64(high) * 1e10 + uint64(low)}, nil
+}
+
+// Columns decimalSum adds up decimal and money values from, the sum and count of the unscaled values followed by
+// the currency and the number of currencies, which are null and 0 for decimals
+const (
+	decimalSumExpr = "SUM(agg.value), COUNT(agg.value), NULL, 0"
+	currenciesExpr = "COUNT(DISTINCT substr(agg.value, 1, 3))"
+)
+
+var moneySumExpr = fmt.Sprintf("SUM(CAST(substr(agg.value, 4) AS INTEGER) - %d), COUNT(agg.value), MIN(substr(agg.value, 1, 3)), %s",
+	storedMoneyOffset, currenciesExpr)
+
+// decimalSum returns the exact sum of decimal or money values, or their average rounded half away from zero
+// at the scale of t, sqlite fails with integer overflow before a sum can wrap around
+func decimalSum(f AggregateFunc, t AttributeType, raw []any) (Value, error) {
+	sum, _ := raw[0].(int64)
+	count, _ := raw[1].(int64)
+	currencies, _ := raw[3].(int64)
+
+	if currencies > 1 {
+		return Value{}, ErrMixedCurrencies
+	}
+
+	if count == 0 {
+		return Value{Type: t}, nil
+	}
+
+	_, scale, _ := t.DecimalParams()
+	d := Decimal{sum, scale}
+	if f == AggAvg {
+		d.Unscaled = sum / count
+		if remainder := sum % count; 2 * max(remainder, -remainder) >= count {
+			if sum < 0 {
+				d.Unscaled--
+			} else {
+				d.Unscaled++
+			}
+		}
+	}
+
+	if t.Base() == MoneyType {
+		currency, _ := raw[2].(string)
+		return Value{t, Money{d, currency}}, nil
+	}
+
+	return Value{t, d}, nil
+}
+
+func (q *Queries) Aggregate(ctx context.Context, arg AggregateQuery) ([]AggregateRow, error) {
+	if !ValidAggregateFunc(string(arg.Func)) {
+		return nil, fmt.Errorf("'%s' unsupported aggregate function", arg.Func)
+	}
+
+	entity, err := q.GetEntity(ctx, GetEntityParam{WithAttributes: true, Field: ByID, Value: arg.EntityID})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get entity %v to aggregate items of: %w", arg.EntityID, err)
+	}
+
+	var attribute Attribute
+	if arg.Attribute != "" {
+		linked, ok := entity.attribute(arg.Attribute)
+		if !ok {
+			return nil, &ValidationError{Field: arg.Attribute, Err: fmt.Errorf("%w: not linked to %s", ErrNotFound, entity.Slug)}
+		}
+
+		attribute = linked.Attribute
+	} else if arg.Func != AggCount {
+		return nil, fmt.Errorf("%s needs an attribute to aggregate", arg.Func)
+	}
+
+	var aggExpr string
+	var resultType AttributeType
+	switch arg.Func {
+	case AggCount:
+		aggExpr = "COUNT(items.id)"
+		if arg.Attribute != "" {
+			aggExpr = "COUNT(agg.value)"
+		}
+		resultType = Int64Type
+	case AggCountDistinct:
+		aggExpr = "COUNT(DISTINCT agg.value)"
+		resultType = Int64Type
+	case AggSum:
+		if !attribute.Type.IsNumeric() {
+			return nil, fmt.Errorf("Can not sum %s, it is of non numeric type %s", attribute.Slug, attribute.Type)
+		}
+		aggExpr = "SUM(agg.value)"
+		resultType = Int64Type
+		if attribute.Type == Float32Type || attribute.Type == Float64Type {
+			resultType = Float64Type
+		}
+	case AggAvg:
+		if !attribute.Type.IsNumeric() {
+			return nil, fmt.Errorf("Can not average %s, it is of non numeric type %s", attribute.Slug, attribute.Type)
+		}
+		aggExpr = "AVG(agg.value)"
+		resultType = Float64Type
+	case AggMin, AggMax:
+		if attribute.Type == BlobType {
+			return nil, fmt.Errorf("Can not take %s of %s, values of type %s are not ordered", arg.Func, attribute.Slug, attribute.Type)
+		}
+		aggExpr = fmt.Sprintf("%s(agg.value)", strings.ToUpper(string(arg.Func)))
+		resultType = attribute.Type
+	}
+
+	// Sums of uint, decimal and money values are added up exactly by combine from the columns aggExpr selects,
+	// money is only aggregated within one currency
+	var combine func(raw []any) (Value, error)
+	aggCols := 1
+	base := attribute.Type.Base()
+	switch {
+	case (arg.Func == AggSum || arg.Func == AggAvg) && isStoredAsText(attribute.Type):
+		aggExpr, aggCols = uintSumExpr, 3
+		combine = func(raw []any) (Value, error) {
+			return uintSum(arg.Func, raw)
+		}
+	case (arg.Func == AggSum || arg.Func == AggAvg) && base == DecimalType:
+		aggExpr, aggCols = decimalSumExpr, 4
+		combine = func(raw []any) (Value, error) {
+			return decimalSum(arg.Func, attribute.Type, raw)
+		}
+	case (arg.Func == AggSum || arg.Func == AggAvg) && base == MoneyType:
+		aggExpr, aggCols = moneySumExpr, 4
+		combine = func(raw []any) (Value, error) {
+			return decimalSum(arg.Func, attribute.Type, raw)
+		}
+	case (arg.Func == AggMin || arg.Func == AggMax) && base == MoneyType:
+		aggExpr, aggCols = aggExpr + ", " + currenciesExpr, 2
+		combine = func(raw []any) (Value, error) {
+			if currencies, _ := raw[1].(int64); currencies > 1 {
+				return Value{}, ErrMixedCurrencies
+			}
+
+			return decodeValue(attribute.Type, raw[0])
+		}
+	}
+
+	var sb strings.Builder
+	var args []any
+	var groupCols []string
+	groupIDs := make([]int64, len(arg.GroupBy))
+	groupTypes := make([]AttributeType, len(arg.GroupBy))
+
+	for idx, slug := range arg.GroupBy {
+		groupAttribute, ok := entity.attribute(slug)
+		if !ok {
+			return nil, &ValidationError{Field: slug, Err: fmt.Errorf("%w: not linked to %s", ErrNotFound, entity.Slug)}
+		}
+
+		groupIDs[idx] = groupAttribute.ID
+		groupTypes[idx] = groupAttribute.Type
+		groupCols = append(groupCols, fmt.Sprintf("g%d.value", idx))
+	}
+
+	sb.WriteString("SELECT ")
+	for _, col := range groupCols {
+		sb.WriteString(col)
+		sb.WriteString(", ")
+	}
+	sb.WriteString(aggExpr)
+	sb.WriteString(" FROM items")
+
+	if attribute.ID != 0 {
+		sb.WriteString(" LEFT JOIN item_attribute AS agg ON agg.item_id = items.id AND agg.attribute_id = ?")
+		args = append(args, attribute.ID)
+	}
+
+	for idx, groupID := range groupIDs {
+		sb.WriteString(fmt.Sprintf(" LEFT JOIN item_attribute AS g%d ON g%d.item_id = items.id AND g%d.attribute_id = ?", idx, idx, idx))
+		args = append(args, groupID)
+	}
+
+	conds, condArgs, err := q.whereItems(ctx, arg.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	sb.WriteString(" WHERE items.entity_id = ? AND items.tenant_id = ?")
+	args = append(args, arg.EntityID, q.tenant)
+
+	for _, cond := range conds {
+		sb.WriteString(" AND ")
+		sb.WriteString(cond)
+	}
+	args = append(args, condArgs...)
+
+	if len(groupCols) > 0 {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(strings.Join(groupCols, ", "))
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(groupCols, ", "))
+	}
+	sb.WriteString(";")
+
+	rows, err := q.queryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer rows.Close()
+
+	var items []AggregateRow
+	for rows.Next() {
+		raw := make([]any, len(groupCols) + aggCols)
+		dest := make([]any, len(raw))
+		for idx := range raw {
+			dest[idx] = &raw[idx]
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return items, classify(err)
+		}
+
+		var i AggregateRow
+		for idx, groupType := range groupTypes {
+			value, err := decodeValue(groupType, raw[idx])
+			if err != nil {
+				return items, fmt.Errorf("Failed to read %s group value: %w", arg.GroupBy[idx], err)
+			}
+
+			i.Group = append(i.Group, value)
+		}
+
+		if combine != nil {
+			i.Value, err = combine(raw[len(groupCols):])
+		} else {
+			i.Value, err = decodeValue(resultType, raw[len(groupCols)])
+		}
+		if err != nil {
+			return items, fmt.Errorf("Failed to read %s of %s: %w", arg.Func, arg.Attribute, err)
+		}
+
+		items = append(items, i)
+	}
+
+	return items, classify(rows.Err())
+}
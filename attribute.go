@@ -3,7 +3,6 @@ package geaves
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"strings"
 	"fmt"
 )
@@ -34,6 +33,10 @@ const (
 
 func ValidAttributeType(t string) bool {
 	v := AttributeType(t)
+	if _, _, ok := v.DecimalParams(); ok {
+		return true
+	}
+
 	return BoolType == v ||
 		StringType == v ||
 		IntType == v ||
@@ -92,8 +95,8 @@ func NewAttribute(name string, slug string, attrType AttributeType) *Attribute {
 }
 
 const createAttribute = `
-INSERT INTO attributes (name, slug, type) VALUES (?, ?, ?)
-RETURNING *;
+INSERT INTO attributes (name, slug, type, tenant_id) VALUES (?, ?, ?, ?)
+RETURNING id, name, slug, type;
 `
 
 type CreateAttributeParam struct {
@@ -103,10 +106,15 @@ type CreateAttributeParam struct {
 }
 
 func (q *Queries) CreateAttribute(ctx context.Context, arg CreateAttributeParam) (Attribute, error) {
-	row := q.db.QueryRowContext(ctx, createAttribute,
+	if !ValidAttributeType(string(arg.Type)) {
+		return Attribute{}, &ValidationError{Field: "type", Value: arg.Type, Err: ErrInvalidType}
+	}
+
+	row := q.queryRowContext(ctx, createAttribute,
 		arg.Name,
 		arg.Slug,
 		arg.Type,
+		q.tenant,
 	)
 
 	var i Attribute
@@ -117,47 +125,59 @@ func (q *Queries) CreateAttribute(ctx context.Context, arg CreateAttributeParam)
 		&i.Type,
 	)
 
-	return i, err
+	return i, classify(err)
 }
 
 const updateAttributeName = `
-UPDATE attributes SET name = ? WHERE id = ?;
+UPDATE attributes SET name = ? WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) UpdateAttributeName(ctx context.Context, name string, id int64) error {
-	_, err := q.db.ExecContext(ctx, updateEntityName, name, id)
-	return err
+	return q.execOne(ctx, updateAttributeName, name, id, q.tenant)
 }
 
 const updateAttributeSlug = `
-UPDATE attributes SET slug = ? WHERE id = ?;
+UPDATE attributes SET slug = ? WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) UpdateAttributeSlug(ctx context.Context, slug string, id int64) error {
-	_, err := q.db.ExecContext(ctx, updateEntitySlug, slug, id)
-	return err
+	if err := q.execOne(ctx, updateAttributeSlug, slug, id, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
 
 const updateAttributeType = `
-UPDATE attributes SET type = ? WHERE id = ?;
+UPDATE attributes SET type = ? WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) UpdateAttributeType(ctx context.Context, newType AttributeType, id int64) error {
-	_, err := q.db.ExecContext(ctx, updateEntitySlug, newType, id)
-	return err
+	if !ValidAttributeType(string(newType)) {
+		return &ValidationError{Field: "type", Value: newType, Err: ErrInvalidType}
+	}
+
+	if err := q.execOne(ctx, updateAttributeType, newType, id, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
 
 const deleteAttribute = `
-DELETE FROM attributes WHERE id = ?;
+DELETE FROM attributes WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) DeleteAttribute(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAttribute, id)
-	return err
+	if err := q.execOne(ctx, deleteAttribute, id, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
 
 const getAttributeNoEntitie = `
-SELECT id, name, slug, type, null FROM attributes WHERE %s = ?;
+SELECT id, name, slug, type, null FROM attributes WHERE %s = ? AND tenant_id = ?;
 `
 
 const getAttributesWithEntities = `
@@ -166,17 +186,26 @@ SELECT
   attributes.name,
   attributes.slug,
   attributes.type,
-  IIF(entity_attribute.attribute.id IS NOT NULL
+  IIF(entity_attribute.attribute_id IS NOT NULL,
     JSON_GROUP_ARRAY(
       JSON_OBJECT('id', entities.id, 'name', entities.name, 'slug', entities.slug, 'required', entity_attribute.required)
     ), NULL
   ) AS entities
 FROM attributes
 LEFT JOIN entity_attribute ON entity_attribute.attribute_id = attributes.id
-INNER JOIN entities ON entity_attribute.entity_id = entities.id
-WHERE %s = ?;
+LEFT JOIN entities ON entity_attribute.entity_id = entities.id
+WHERE %s = ? AND attributes.tenant_id = ?
+GROUP BY attributes.id;
 `
 
+// Variants of the get attribute queries by GetType, distinct statements so each can be prepared
+var (
+	getAttributeByID = fmt.Sprintf(getAttributeNoEntitie, "attributes.id")
+	getAttributeBySlug = fmt.Sprintf(getAttributeNoEntitie, "attributes.slug")
+	getAttributeByIDWithEntities = fmt.Sprintf(getAttributesWithEntities, "attributes.id")
+	getAttributeBySlugWithEntities = fmt.Sprintf(getAttributesWithEntities, "attributes.slug")
+)
+
 type GetAttributeParam struct {
 	WithEntities bool
 	Field GetType
@@ -184,33 +213,31 @@ type GetAttributeParam struct {
 }
 
 func (q *Queries) GetAttribute(ctx context.Context, arg GetAttributeParam) (Attribute, error) {
+	var query string
 	switch arg.Field {
 	case ByID:
 		if _, ok := arg.Value.(int64); !ok {
-			return Attribute{}, errors.New("GetAttributeParam's value has wrong type")
+			return Attribute{}, &ValidationError{Field: "GetAttributeParam.Value", Value: arg.Value, Err: ErrInvalidType}
+		}
+		query = getAttributeByID
+		if arg.WithEntities {
+			query = getAttributeByIDWithEntities
 		}
-		arg.Value = arg.Value.(int64)
-		arg.Field = "attributes.id"
 		break;
 	case BySlug:
 		if _, ok := arg.Value.(string); !ok {
-			return Attribute{}, errors.New("GetAttributeParam's vlaue has wrong type")
+			return Attribute{}, &ValidationError{Field: "GetAttributeParam.Value", Value: arg.Value, Err: ErrInvalidType}
+		}
+		query = getAttributeBySlug
+		if arg.WithEntities {
+			query = getAttributeBySlugWithEntities
 		}
-		arg.Value = arg.Value.(string)
-		arg.Field = "attributes.slug"
 		break;
 	default:
-		return Attribute{}, fmt.Errorf("'%s' unsupported field to look for entity", arg.Field)
+		return Attribute{}, &ValidationError{Field: "GetAttributeParam.Field", Value: arg.Field, Err: ErrInvalidType}
 	}
 
-	var query string
-	if arg.WithEntities {
-		query = getAttributesWithEntities
-	} else {
-		query = getAttributeNoEntitie
-	}
-
-	row := q.db.QueryRowContext(ctx, fmt.Sprintf(query, arg.Field), arg.Value)
+	row := q.queryRowContext(ctx, query, arg.Value, q.tenant)
 
 	var i Attribute
 	var entitiesJson *string
@@ -221,7 +248,7 @@ func (q *Queries) GetAttribute(ctx context.Context, arg GetAttributeParam) (Attr
 		&i.Type,
 		&entitiesJson,
 	); err != nil {
-		return i, err
+		return i, classify(err)
 	}
 
 	if arg.WithEntities {
@@ -241,7 +268,7 @@ func (q *Queries) GetAttribute(ctx context.Context, arg GetAttributeParam) (Attr
 }
 
 const listAttributesNoEntities = `
-SELECT id, name, slug, type, null FROM attributes
+SELECT id, name, slug, type, null FROM attributes WHERE tenant_id = ?;
 `
 
 const listAttributesWithEntities = `
@@ -250,14 +277,15 @@ SELECT
   attributes.name,
   attributes.slug,
   attributes.type,
-  IIF(entity_attribute.attribute_id IS NOT NULL
+  IIF(entity_attribute.attribute_id IS NOT NULL,
     JSON_GROUP_ARRAY(
       JSON_OBJECT('id', entities.id, 'name', entities.name, 'slug', entities.slug, 'required', entity_attribute.required)
     ), NULL
   ) AS entities
 FROM attributes
 LEFT JOIN entity_attribute ON entity_attribute.attribute_id = attributes.id
-INNER JOIN entities ON entity_attribute.entity_id = entities.id
+LEFT JOIN entities ON entity_attribute.entity_id = entities.id
+WHERE attributes.tenant_id = ?
 GROUP BY attributes.id;
 `
 
@@ -269,9 +297,9 @@ func (q *Queries) ListAttributes(ctx context.Context, withEntities bool) ([]Attr
 		query = listAttributesNoEntities
 	}
 
-	rows, err := q.db.QueryContext(ctx, query)
+	rows, err := q.queryContext(ctx, query, q.tenant)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 	defer rows.Close()
 
@@ -287,7 +315,7 @@ func (q *Queries) ListAttributes(ctx context.Context, withEntities bool) ([]Attr
 			&i.Type,
 			&entitiesJson,
 		); err != nil {
-			return nil, err
+			return nil, classify(err)
 		}
 
 		if withEntities {
@@ -306,7 +334,7 @@ func (q *Queries) ListAttributes(ctx context.Context, withEntities bool) ([]Attr
 		items = append(items, i)
 	}
 
-	return items, nil
+	return items, classify(rows.Err())
 }
 
 const loadEntitiesByAttribute = `
@@ -317,18 +345,22 @@ SELECT IIF(entity_attribute.attribute_id IS NOT NULL,
 ) AS entities
 FROM attributes
 LEFT JOIN entity_attribute ON entity_attribute.attribute_id = attributes.id
-INNER JOIN entities ON entity_attribute.entity_id = entities.id
-WHERE attributes.id = ?;
+LEFT JOIN entities ON entity_attribute.entity_id = entities.id
+WHERE attributes.id = ? AND attributes.tenant_id = ?;
 `
 
 func (q *Queries) LoadEntitiesByAttribute(ctx context.Context, id int64) ([]attributeEntityEmbed, error) {
-	row := q.db.QueryRowContext(ctx, loadEntitiesByAttribute, id)
+	row := q.queryRowContext(ctx, loadEntitiesByAttribute, id, q.tenant)
 
 	var entitiesJson *string
 	if err := row.Scan(
 		&entitiesJson,
 	); err != nil {
-		return nil, err
+		return nil, classify(err)
+	}
+
+	if entitiesJson == nil {
+		return nil, nil
 	}
 
 	entities, err := parseEntitiesJson(*entitiesJson)
@@ -0,0 +1,197 @@
+package geaves
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// bulkMaxParams is the most parameters one statement of BulkCreateItems binds, the limit of sqlite before 3.32,
+// larger statements are allowed by newer versions but bind slower than they save in round trips
+const bulkMaxParams = 999
+
+// bulkInsert inserts rows of width parameters, chunked to stay under bulkMaxParams, the statement for a chunk
+// of a given number of rows is prepared once and reused for every chunk of that size
+type bulkInsert struct {
+	q *Queries
+	// head is the statement up to its VALUES, tuple the parameters of one row and tail what follows the rows
+	head string
+	tuple string
+	tail string
+	width int
+	stmts map[int]*sql.Stmt
+}
+
+func (b *bulkInsert) chunkSize() int {
+	return bulkMaxParams / b.width
+}
+
+func (b *bulkInsert) stmt(ctx context.Context, rows int) (*sql.Stmt, error) {
+	if stmt, ok := b.stmts[rows]; ok {
+		return stmt, nil
+	}
+
+	query := b.head + strings.Repeat(b.tuple + ", ", rows - 1) + b.tuple + b.tail
+	stmt, err := b.q.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	if b.stmts == nil {
+		b.stmts = map[int]*sql.Stmt{}
+	}
+	b.stmts[rows] = stmt
+	return stmt, nil
+}
+
+func (b *bulkInsert) close() {
+	for _, stmt := range b.stmts {
+		stmt.Close()
+	}
+}
+
+type bulkValue struct {
+	row int
+	attributeID int64
+	stored any
+}
+
+// BulkCreateItems creates an item of entity for every row, with the values of the row by attribute slug,
+// and returns the ids of the created items in the order of rows.
+// Every row is checked before anything is written, values must be of the Go type of their attribute (see Set),
+// and every required attribute needs one. The items and values are written in one transaction (see RunInTx)
+// with prepared multi-row inserts, so q is best bound to a *sql.DB or transaction without other work pending
+func (q *Queries) BulkCreateItems(ctx context.Context, entity Entity, rows []map[string]any) ([]int64, error) {
+	attributes, err := q.LoadAttributesByEntity(ctx, entity.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get attributes of entity: %w", err)
+	}
+
+	linked := make(map[string]EntityAttributeEmbed, len(attributes))
+	for _, attribute := range attributes {
+		linked[attribute.Slug] = attribute
+	}
+
+	// Go types are checked once per attribute and type, the values themselves are still range checked by encodeValue
+	type checkKey struct {
+		attributeID int64
+		typ reflect.Type
+	}
+	checked := map[checkKey]bool{}
+
+	var values []bulkValue
+	for idx, row := range rows {
+		for slug, data := range row {
+			attribute, ok := linked[slug]
+			if !ok {
+				return nil, fmt.Errorf("Row %v: %w", idx, &ValidationError{Field: slug, Err: fmt.Errorf("%w: not linked to %s", ErrNotFound, entity.Slug)})
+			}
+
+			if data == nil {
+				continue
+			}
+
+			key := checkKey{attribute.ID, reflect.TypeOf(data)}
+			if !checked[key] {
+				if err := checkGoType(attribute.Attribute, key.typ, true); err != nil {
+					return nil, fmt.Errorf("Row %v: %w", idx, err)
+				}
+				checked[key] = true
+			}
+
+			stored, err := encodeValue(Value{Type: attribute.Type, Data: data})
+			if err != nil {
+				return nil, fmt.Errorf("Row %v: %w", idx, &ValidationError{Field: slug, Value: data, Err: fmt.Errorf("%w: %w", ErrInvalidType, err)})
+			}
+
+			values = append(values, bulkValue{idx, attribute.ID, stored})
+		}
+
+		for _, attribute := range attributes {
+			if attribute.Required && row[attribute.Slug] == nil {
+				return nil, fmt.Errorf("Row %v: %w", idx, &ValidationError{Field: attribute.Slug, Err: ErrRequiredMissing})
+			}
+		}
+	}
+
+	var ids []int64
+	err = q.RunInTx(ctx, func(q *Queries) error {
+		// Set again when the transaction is retried
+		ids = make([]int64, 0, len(rows))
+
+		items := &bulkInsert{q: q, head: "INSERT INTO items (entity_id, tenant_id) VALUES ", tuple: "(?, ?)", tail: " RETURNING id;", width: 2}
+		defer items.close()
+
+		for start := 0; start < len(rows); start += items.chunkSize() {
+			n := min(items.chunkSize(), len(rows) - start)
+			stmt, err := items.stmt(ctx, n)
+			if err != nil {
+				return fmt.Errorf("Failed to prepare item insert: %w", err)
+			}
+
+			args := make([]any, 0, n * 2)
+			for range n {
+				args = append(args, entity.ID, q.tenant)
+			}
+
+			chunk, err := scanIds(ctx, stmt, args)
+			if err != nil {
+				return fmt.Errorf("Failed to create items: %w", err)
+			}
+
+			// Rows are returned in no particular order, but their ids are handed out in the order of the rows
+			slices.Sort(chunk)
+			ids = append(ids, chunk...)
+		}
+
+		itemValues := &bulkInsert{q: q, head: "INSERT INTO item_attribute (item_id, attribute_id, value) VALUES ", tuple: "(?, ?, ?)", tail: ";", width: 3}
+		defer itemValues.close()
+
+		for start := 0; start < len(values); start += itemValues.chunkSize() {
+			n := min(itemValues.chunkSize(), len(values) - start)
+			stmt, err := itemValues.stmt(ctx, n)
+			if err != nil {
+				return fmt.Errorf("Failed to prepare value insert: %w", err)
+			}
+
+			args := make([]any, 0, n * 3)
+			for _, value := range values[start:start + n] {
+				args = append(args, ids[value.row], value.attributeID, value.stored)
+			}
+
+			if _, err := stmt.ExecContext(ctx, args...); err != nil {
+				return fmt.Errorf("Failed to set values: %w", classify(err))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func scanIds(ctx context.Context, stmt *sql.Stmt, args []any) ([]int64, error) {
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, len(args))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return ids, classify(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, classify(rows.Err())
+}
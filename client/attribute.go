@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Asfolny/geaves"
+	"github.com/Asfolny/geaves/server"
+)
+
+func attributePath(id int64) string {
+	return fmt.Sprintf("/attributes/%d", id)
+}
+
+func toAttribute(a server.Attribute) geaves.Attribute {
+	return geaves.Attribute{ID: a.ID, Name: a.Name, Slug: a.Slug, Type: a.Type}
+}
+
+func (c *Client) CreateAttribute(ctx context.Context, arg geaves.CreateAttributeParam) (geaves.Attribute, error) {
+	var res server.Attribute
+	err := c.do(ctx, http.MethodPost, "/attributes", server.CreateAttributeRequest{Name: arg.Name, Slug: arg.Slug, Type: arg.Type}, &res)
+	return toAttribute(res), err
+}
+
+func (c *Client) UpdateAttributeName(ctx context.Context, name string, id int64) error {
+	return c.do(ctx, http.MethodPatch, attributePath(id), server.UpdateAttributeRequest{Name: &name}, nil)
+}
+
+func (c *Client) UpdateAttributeSlug(ctx context.Context, slug string, id int64) error {
+	return c.do(ctx, http.MethodPatch, attributePath(id), server.UpdateAttributeRequest{Slug: &slug}, nil)
+}
+
+func (c *Client) UpdateAttributeType(ctx context.Context, newType geaves.AttributeType, id int64) error {
+	return c.do(ctx, http.MethodPatch, attributePath(id), server.UpdateAttributeRequest{Type: &newType}, nil)
+}
+
+func (c *Client) DeleteAttribute(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, attributePath(id), nil, nil)
+}
+
+// GetAttribute looks up an attribute, the entities of the returned attribute are not loaded regardless of arg.WithEntities
+func (c *Client) GetAttribute(ctx context.Context, arg geaves.GetAttributeParam) (geaves.Attribute, error) {
+	key, err := pathKey(arg.Field, arg.Value)
+	if err != nil {
+		return geaves.Attribute{}, err
+	}
+
+	var res server.Attribute
+	err = c.do(ctx, http.MethodGet, "/attributes/" + key, nil, &res)
+	return toAttribute(res), err
+}
+
+// ListAttributes lists every attribute, like GetAttribute the entities are never loaded
+func (c *Client) ListAttributes(ctx context.Context, withEntities bool) ([]geaves.Attribute, error) {
+	var res []server.Attribute
+	if err := c.do(ctx, http.MethodGet, "/attributes", nil, &res); err != nil {
+		return nil, err
+	}
+
+	attributes := make([]geaves.Attribute, len(res))
+	for idx, a := range res {
+		attributes[idx] = toAttribute(a)
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,152 @@
+// Package client talks to a geaves HTTP API (see the server package) with methods mirroring geaves.Queries
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Asfolny/geaves"
+	"github.com/Asfolny/geaves/server"
+)
+
+var (
+	// ErrBadRequest is matched by errors the server rejected as invalid input, 400
+	ErrBadRequest = errors.New("bad request")
+	// ErrNotFound is matched by errors for entities, attributes, items or values that do not exist, 404
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is matched by errors breaking a constraint, such as a slug that is already taken, 409
+	ErrConflict = errors.New("conflict")
+)
+
+// Error is returned for every response with a status of 400 or above, use errors.Is with
+// ErrBadRequest, ErrNotFound or ErrConflict to tell them apart, or with the geaves errors like
+// geaves.ErrDuplicateSlug named by Code
+type Error struct {
+	StatusCode int
+	Message string
+	Code string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	default:
+		sentinel := server.ErrorForCode(e.Code)
+		return sentinel != nil && errors.Is(sentinel, target)
+	}
+}
+
+type Client struct {
+	baseURL string
+	httpClient *http.Client
+}
+
+type ClientOption func(*Client)
+
+// WithHTTPClient sends requests with c instead of http.DefaultClient, for example the Client of an httptest.Server
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(cl *Client) {
+		cl.httpClient = c
+	}
+}
+
+// New returns a Client for the API served at baseURL, such as http://localhost:8080 or the URL of an httptest.Server
+func New(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// do sends body as json and decodes the response into out, when out is not nil
+func (c *Client) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL + path, reader)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		var apiErr server.Error
+		if err := json.NewDecoder(res.Body).Decode(&apiErr); err != nil || apiErr.Error == "" {
+			apiErr.Error = http.StatusText(res.StatusCode)
+		}
+
+		return &Error{StatusCode: res.StatusCode, Message: apiErr.Error, Code: apiErr.Code}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("Failed to decode response of %s %s: %w", method, path, err)
+	}
+
+	return nil
+}
+
+// pathKey is how a geaves.GetType lookup is put in a path, the server reads numbers as ids and anything else as slugs
+func pathKey(field geaves.GetType, value any) (string, error) {
+	switch field {
+	case geaves.ByID:
+		id, ok := value.(int64)
+		if !ok {
+			return "", errors.New("Lookup by id needs an int64 value")
+		}
+
+		return strconv.FormatInt(id, 10), nil
+	case geaves.BySlug:
+		slug, ok := value.(string)
+		if !ok {
+			return "", errors.New("Lookup by slug needs a string value")
+		}
+
+		return url.PathEscape(slug), nil
+	default:
+		return "", fmt.Errorf("'%s' unsupported field to look up", field)
+	}
+}
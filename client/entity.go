@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Asfolny/geaves"
+	"github.com/Asfolny/geaves/server"
+)
+
+func entityPath(id int64) string {
+	return fmt.Sprintf("/entities/%d", id)
+}
+
+func toEntity(e server.Entity) geaves.Entity {
+	return geaves.Entity{ID: e.ID, Name: e.Name, Slug: e.Slug}
+}
+
+func toEntityAttributes(attributes []server.EntityAttribute) []geaves.EntityAttributeEmbed {
+	res := make([]geaves.EntityAttributeEmbed, len(attributes))
+	for idx, a := range attributes {
+		res[idx] = geaves.EntityAttributeEmbed{
+			Attribute: geaves.Attribute{ID: a.ID, Name: a.Name, Slug: a.Slug, Type: a.Type},
+			Required: a.Required,
+		}
+	}
+
+	return res
+}
+
+func (c *Client) CreateEntity(ctx context.Context, arg geaves.CreateEntityParam) (geaves.Entity, error) {
+	var res server.Entity
+	err := c.do(ctx, http.MethodPost, "/entities", server.CreateEntityRequest{Name: arg.Name, Slug: arg.Slug}, &res)
+	return toEntity(res), err
+}
+
+func (c *Client) UpdateEntityName(ctx context.Context, name string, id int64) error {
+	return c.do(ctx, http.MethodPatch, entityPath(id), server.UpdateEntityRequest{Name: &name}, nil)
+}
+
+func (c *Client) UpdateEntitySlug(ctx context.Context, slug string, id int64) error {
+	return c.do(ctx, http.MethodPatch, entityPath(id), server.UpdateEntityRequest{Slug: &slug}, nil)
+}
+
+func (c *Client) DeleteEntity(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, entityPath(id), nil, nil)
+}
+
+// GetEntity looks up an entity, the attributes of the returned entity are not loaded regardless of
+// arg.WithAttributes, use LoadAttributesByEntity for them
+func (c *Client) GetEntity(ctx context.Context, arg geaves.GetEntityParam) (geaves.Entity, error) {
+	key, err := pathKey(arg.Field, arg.Value)
+	if err != nil {
+		return geaves.Entity{}, err
+	}
+
+	var res server.Entity
+	err = c.do(ctx, http.MethodGet, "/entities/" + key, nil, &res)
+	return toEntity(res), err
+}
+
+// ListEntities lists every entity, like GetEntity the attributes are never loaded
+func (c *Client) ListEntities(ctx context.Context, withAttributes bool) ([]geaves.Entity, error) {
+	var res []server.Entity
+	if err := c.do(ctx, http.MethodGet, "/entities", nil, &res); err != nil {
+		return nil, err
+	}
+
+	entities := make([]geaves.Entity, len(res))
+	for idx, e := range res {
+		entities[idx] = toEntity(e)
+	}
+
+	return entities, nil
+}
+
+func (c *Client) LoadAttributesByEntity(ctx context.Context, id int64) ([]geaves.EntityAttributeEmbed, error) {
+	var res []server.EntityAttribute
+	if err := c.do(ctx, http.MethodGet, entityPath(id) + "/attributes", nil, &res); err != nil {
+		return nil, err
+	}
+
+	return toEntityAttributes(res), nil
+}
+
+func (c *Client) CreateEntityAttribute(ctx context.Context, arg geaves.EntityAttribute) (geaves.EntityAttribute, error) {
+	path := fmt.Sprintf("%s/attributes/%d", entityPath(arg.EntityID), arg.AttributeID)
+
+	var res server.EntityAttribute
+	if err := c.do(ctx, http.MethodPut, path, server.LinkRequest{Required: arg.Required}, &res); err != nil {
+		return geaves.EntityAttribute{}, err
+	}
+
+	return geaves.EntityAttribute{EntityID: arg.EntityID, AttributeID: res.ID, Required: res.Required}, nil
+}
+
+func (c *Client) UpdateRequireEntityAttribute(ctx context.Context, req bool, entityId int64, attributeId int64) error {
+	path := fmt.Sprintf("%s/attributes/%d", entityPath(entityId), attributeId)
+	return c.do(ctx, http.MethodPut, path, server.LinkRequest{Required: req}, nil)
+}
+
+func (c *Client) DeleteEntityAttribute(ctx context.Context, entityId int64, attributeId int64) error {
+	path := fmt.Sprintf("%s/attributes/%d", entityPath(entityId), attributeId)
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Asfolny/geaves"
+	"github.com/Asfolny/geaves/server"
+)
+
+func itemPath(id int64) string {
+	return fmt.Sprintf("/items/%d", id)
+}
+
+func itemValuePath(itemId int64, attributeId int64) string {
+	return fmt.Sprintf("/items/%d/values/%d", itemId, attributeId)
+}
+
+func toItemValue(itemId int64, v server.ItemValue) (geaves.ItemValue, error) {
+	value, err := geaves.DecodeJSONValue(v.Type, v.Value)
+	if err != nil {
+		return geaves.ItemValue{}, fmt.Errorf("Invalid %s on item %v: %w", v.Attribute, itemId, err)
+	}
+
+	return geaves.ItemValue{ItemID: itemId, AttributeID: v.AttributeID, Slug: v.Attribute, Value: value}, nil
+}
+
+// CreateItem creates an item without values, the server refuses this for entities with required attributes,
+// use CreateItemWithValues for those
+func (c *Client) CreateItem(ctx context.Context, entityId int64) (geaves.Item, error) {
+	return c.CreateItemWithValues(ctx, entityId, nil)
+}
+
+// CreateItemWithValues creates an item with values by attribute slug in one request
+func (c *Client) CreateItemWithValues(ctx context.Context, entityId int64, values map[string]geaves.Value) (geaves.Item, error) {
+	req := server.CreateItemRequest{Values: make(map[string]json.RawMessage, len(values))}
+	for slug, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return geaves.Item{}, fmt.Errorf("Failed to encode %s: %w", slug, err)
+		}
+
+		req.Values[slug] = data
+	}
+
+	var res server.Item
+	err := c.do(ctx, http.MethodPost, entityPath(entityId) + "/items", req, &res)
+	return geaves.Item{ID: res.ID, EntityID: res.EntityID}, err
+}
+
+func (c *Client) DeleteItem(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, itemPath(id), nil, nil)
+}
+
+func (c *Client) GetItem(ctx context.Context, id int64) (geaves.Item, error) {
+	var res server.Item
+	err := c.do(ctx, http.MethodGet, itemPath(id), nil, &res)
+	return geaves.Item{ID: res.ID, EntityID: res.EntityID}, err
+}
+
+func (c *Client) ListItems(ctx context.Context) ([]geaves.Item, error) {
+	var res []server.Item
+	if err := c.do(ctx, http.MethodGet, "/items", nil, &res); err != nil {
+		return nil, err
+	}
+
+	items := make([]geaves.Item, len(res))
+	for idx, i := range res {
+		items[idx] = geaves.Item{ID: i.ID, EntityID: i.EntityID}
+	}
+
+	return items, nil
+}
+
+// ListEntityItems lists the items of an entity, like QueryItems without predicates
+func (c *Client) ListEntityItems(ctx context.Context, entityId int64) ([]geaves.Item, error) {
+	var res []server.Item
+	if err := c.do(ctx, http.MethodGet, entityPath(entityId) + "/items", nil, &res); err != nil {
+		return nil, err
+	}
+
+	items := make([]geaves.Item, len(res))
+	for idx, i := range res {
+		items[idx] = geaves.Item{ID: i.ID, EntityID: i.EntityID}
+	}
+
+	return items, nil
+}
+
+// ListItemValues lists the values of an item decoded into the Go type of their attribute
+func (c *Client) ListItemValues(ctx context.Context, itemId int64) ([]geaves.ItemValue, error) {
+	var res []server.ItemValue
+	if err := c.do(ctx, http.MethodGet, itemPath(itemId) + "/values", nil, &res); err != nil {
+		return nil, err
+	}
+
+	values := make([]geaves.ItemValue, len(res))
+	for idx, v := range res {
+		value, err := toItemValue(itemId, v)
+		if err != nil {
+			return nil, err
+		}
+
+		values[idx] = value
+	}
+
+	return values, nil
+}
+
+// GetItemValue gets a single value of an item, an error matching ErrNotFound is returned when it has none
+func (c *Client) GetItemValue(ctx context.Context, itemId int64, attributeId int64) (geaves.ItemValue, error) {
+	var res server.ItemValue
+	if err := c.do(ctx, http.MethodGet, itemValuePath(itemId, attributeId), nil, &res); err != nil {
+		return geaves.ItemValue{}, err
+	}
+
+	return toItemValue(itemId, res)
+}
+
+// SetItemValue stores value on an item, whether or not the item already has a value for the attribute
+func (c *Client) SetItemValue(ctx context.Context, itemId int64, attributeId int64, value geaves.Value) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPut, itemValuePath(itemId, attributeId), server.SetValueRequest{Value: data}, nil)
+}
+
+func (c *Client) DeleteItemAttributes(ctx context.Context, itemId int64, attributeId int64) error {
+	return c.do(ctx, http.MethodDelete, itemValuePath(itemId, attributeId), nil, nil)
+}
@@ -0,0 +1,379 @@
+package geaves
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type CSVImportOptions struct {
+	// Comma separates fields, defaults to ','
+	Comma rune
+	// Mapping maps header columns to attribute slugs, columns not in it are taken to be slugs,
+	// columns mapped to "" are skipped
+	Mapping map[string]string
+	// CreateMissing creates attributes (as strings) for columns without one and links
+	// attributes not yet linked to the entity as optional
+	CreateMissing bool
+}
+
+// CSVRowError is a problem with a single cell, or a whole row when Column is empty
+type CSVRowError struct {
+	Line int
+	Column string
+	Err error
+}
+
+func (e *CSVRowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %v: %v", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("line %v, column %s: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *CSVRowError) Unwrap() error {
+	return e.Err
+}
+
+// CSVImportError collects every row that failed to parse, nothing is written when it is returned
+type CSVImportError struct {
+	Rows []*CSVRowError
+}
+
+func (e *CSVImportError) Error() string {
+	lines := make([]string, len(e.Rows))
+	for idx, row := range e.Rows {
+		lines[idx] = row.Error()
+	}
+
+	return fmt.Sprintf("%v rows failed to import:\n%s", len(e.Rows), strings.Join(lines, "\n"))
+}
+
+func (e *CSVImportError) Unwrap() []error {
+	errs := make([]error, len(e.Rows))
+	for idx, row := range e.Rows {
+		errs[idx] = row
+	}
+
+	return errs
+}
+
+type CSVImportResult struct {
+	ItemIDs []int64
+	AttributesCreated []string
+	AttributesLinked []string
+}
+
+type csvColumn struct {
+	header string
+	attribute EntityAttributeEmbed
+	skip bool
+}
+
+// ImportCSV creates an item of entityID per row of r, the first row is the header naming the attribute
+// of each column. Every row is parsed before anything is written, when any fails a *CSVImportError
+// listing all failing rows is returned. Empty cells are left without a value.
+// Attributes created for missing columns and the items are written in one transaction (see RunInTx)
+func ImportCSV(ctx context.Context, q *Queries, entityID int64, r io.Reader, opts CSVImportOptions) (CSVImportResult, error) {
+	// Read up front, a retried transaction reads the rows again
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return CSVImportResult{}, fmt.Errorf("Failed to read csv: %w", err)
+	}
+
+	var res CSVImportResult
+	err = q.RunInTx(ctx, func(q *Queries) error {
+		var err error
+		res, err = importCSV(ctx, q, entityID, bytes.NewReader(data), opts)
+		return err
+	})
+
+	return res, err
+}
+
+func importCSV(ctx context.Context, q *Queries, entityID int64, r io.Reader, opts CSVImportOptions) (CSVImportResult, error) {
+	var res CSVImportResult
+
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return res, fmt.Errorf("Failed to read csv header: %w", err)
+	}
+
+	attributes, err := q.LoadAttributesByEntity(ctx, entityID)
+	if err != nil {
+		return res, fmt.Errorf("Failed to get attributes of entity: %w", err)
+	}
+
+	linked := make(map[string]EntityAttributeEmbed, len(attributes))
+	for _, attribute := range attributes {
+		linked[attribute.Slug] = attribute
+	}
+
+	columns := make([]csvColumn, len(header))
+	seen := map[string]bool{}
+	for idx, name := range header {
+		columns[idx].header = name
+
+		slug, mapped := opts.Mapping[name]
+		if !mapped {
+			slug = name
+		}
+
+		if slug == "" {
+			columns[idx].skip = true
+			continue
+		}
+
+		if seen[slug] {
+			return res, fmt.Errorf("Column %s maps to attribute %s, which already has a column", name, slug)
+		}
+		seen[slug] = true
+
+		if attribute, ok := linked[slug]; ok {
+			columns[idx].attribute = attribute
+			continue
+		}
+
+		attribute, err := q.GetAttribute(ctx, GetAttributeParam{Field: BySlug, Value: slug})
+		switch {
+		case errors.Is(err, sql.ErrNoRows) && !mapped && slug == "id":
+			// The item id written by ExportCSV, items always get new ids
+			columns[idx].skip = true
+			continue
+		case errors.Is(err, sql.ErrNoRows) && opts.CreateMissing:
+			attribute, err = q.CreateAttribute(ctx, CreateAttributeParam{name, slug, StringType})
+			if err != nil {
+				return res, fmt.Errorf("Failed to create attribute %s: %w", slug, err)
+			}
+			res.AttributesCreated = append(res.AttributesCreated, slug)
+		case errors.Is(err, sql.ErrNoRows):
+			return res, fmt.Errorf("Column %s has no attribute %s", name, slug)
+		case err != nil:
+			return res, fmt.Errorf("Failed to get attribute %s: %w", slug, err)
+		}
+
+		if !opts.CreateMissing {
+			return res, fmt.Errorf("Column %s has attribute %s, which is not linked to the entity", name, slug)
+		}
+
+		_, err = q.CreateEntityAttribute(ctx, EntityAttribute{entityID, attribute.ID, false})
+		if err != nil {
+			return res, fmt.Errorf("Failed to link attribute %s: %w", slug, err)
+		}
+
+		res.AttributesLinked = append(res.AttributesLinked, slug)
+		columns[idx].attribute = EntityAttributeEmbed{attribute, false}
+	}
+
+	for _, attribute := range attributes {
+		if attribute.Required && !seen[attribute.Slug] {
+			return res, fmt.Errorf("No column for %w", &ValidationError{Field: attribute.Slug, Err: ErrRequiredMissing})
+		}
+	}
+
+	var rows [][]Value
+	var rowErrs []*CSVRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrs = append(rowErrs, &CSVRowError{Line: parseErr.Line, Err: parseErr.Err})
+				continue
+			}
+
+			return res, fmt.Errorf("Failed to read csv: %w", err)
+		}
+
+		// FieldPos panics after a failed Read, so only ask for the line of records that were read
+		line, _ := reader.FieldPos(0)
+
+		row := make([]Value, len(columns))
+		for idx, cell := range record {
+			column := columns[idx]
+			if column.skip {
+				continue
+			}
+
+			if cell == "" {
+				if column.attribute.Required {
+					rowErrs = append(rowErrs, &CSVRowError{line, column.header, &ValidationError{Field: column.attribute.Slug, Err: ErrRequiredMissing}})
+				}
+				continue
+			}
+
+			row[idx], err = ParseValue(column.attribute.Type, cell)
+			if err != nil {
+				rowErrs = append(rowErrs, &CSVRowError{line, column.header, err})
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rowErrs) > 0 {
+		return res, &CSVImportError{rowErrs}
+	}
+
+	bulkRows := make([]map[string]any, len(rows))
+	for idx, row := range rows {
+		bulkRows[idx] = map[string]any{}
+		for col, value := range row {
+			if !value.IsNull() {
+				bulkRows[idx][columns[col].attribute.Slug] = value.Data
+			}
+		}
+	}
+
+	res.ItemIDs, err = q.BulkCreateItems(ctx, Entity{ID: entityID}, bulkRows)
+	if err != nil {
+		return res, fmt.Errorf("Failed to create items: %w", err)
+	}
+
+	return res, nil
+}
+
+type CSVExportOptions struct {
+	// Comma separates fields, defaults to ','
+	Comma rune
+	// Columns are the attribute slugs to write, in order, defaults to every linked attribute
+	// with required attributes first
+	Columns []string
+}
+
+const listEntityItemValues = `
+SELECT items.id, item_attribute.attribute_id, item_attribute.value
+FROM items
+LEFT JOIN item_attribute ON item_attribute.item_id = items.id
+WHERE items.entity_id = ? AND items.tenant_id = ?
+ORDER BY items.id;
+`
+
+// ExportCSV writes the items of entityID to w as csv, one row per item with an id column followed by a column
+// per attribute named by its slug, values are written with FormatValue so the output can be read by ImportCSV.
+// Rows are written as they are read from the database.
+// Csv has no way to tell an empty string from a missing value, both are written as an empty cell, so string
+// values set to "" are read back by ImportCSV as not set
+func ExportCSV(ctx context.Context, q *Queries, entityID int64, w io.Writer, opts CSVExportOptions) error {
+	attributes, err := q.LoadAttributesByEntity(ctx, entityID)
+	if err != nil {
+		return fmt.Errorf("Failed to get attributes of entity: %w", err)
+	}
+
+	var columns []EntityAttributeEmbed
+	if len(opts.Columns) > 0 {
+		for _, slug := range opts.Columns {
+			idx := slices.IndexFunc(attributes, func(a EntityAttributeEmbed) bool { return a.Slug == slug })
+			if idx < 0 {
+				return fmt.Errorf("Attribute %s is not linked to the entity", slug)
+			}
+
+			columns = append(columns, attributes[idx])
+		}
+	} else {
+		columns = slices.Clone(attributes)
+		slices.SortStableFunc(columns, func(a, b EntityAttributeEmbed) int {
+			switch {
+			case a.Required == b.Required:
+				return 0
+			case a.Required:
+				return -1
+			default:
+				return 1
+			}
+		})
+	}
+
+	columnIdx := make(map[int64]int, len(columns))
+	header := make([]string, len(columns) + 1)
+	header[0] = "id"
+	for idx, column := range columns {
+		columnIdx[column.ID] = idx + 1
+		header[idx + 1] = column.Slug
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		writer.Comma = opts.Comma
+	}
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	rows, err := q.queryContext(ctx, listEntityItemValues, entityID, q.tenant)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var record []string
+	var current int64
+	for rows.Next() {
+		var itemID int64
+		var attributeID *int64
+		var raw any
+
+		if err := rows.Scan(&itemID, &attributeID, &raw); err != nil {
+			return err
+		}
+
+		if record == nil || itemID != current {
+			if record != nil {
+				if err := writer.Write(record); err != nil {
+					return err
+				}
+			}
+
+			current = itemID
+			record = make([]string, len(header))
+			record[0] = strconv.FormatInt(itemID, 10)
+		}
+
+		if attributeID == nil {
+			continue
+		}
+
+		idx, ok := columnIdx[*attributeID]
+		if !ok {
+			continue
+		}
+
+		value, err := decodeValue(columns[idx - 1].Type, raw)
+		if err != nil {
+			return fmt.Errorf("Failed to read %s on item %v: %w", columns[idx - 1].Slug, itemID, err)
+		}
+
+		record[idx] = FormatValue(value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if record != nil {
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
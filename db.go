@@ -3,6 +3,9 @@ package geaves
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 )
 
 type DBTX interface {
@@ -18,14 +21,133 @@ func New(db DBTX) *Queries {
 
 type Queries struct {
 	db DBTX
+	// savepoints is how deep RunInTx has nested savepoints on db
+	savepoints int
+	// tx is the transaction the prepared stmts are rebound to, nil when they run on db as prepared
+	tx *sql.Tx
+	// stmts are the statements prepared by Prepare by their query, nil when not prepared
+	stmts map[string]*sql.Stmt
+	// connStmts are stmts prepared again on the connection of a RunInTx transaction on first use,
+	// nil when db is not such a connection
+	connStmts map[string]*sql.Stmt
+	// tenant is the id of the tenant every query is scoped to, see ForTenant
+	tenant int64
 }
 
+// WithTx returns Queries running on tx, statements prepared by Prepare are rebound to it
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
 		db: tx,
+		tx: tx,
+		stmts: q.stmts,
+		tenant: q.tenant,
 	}
 }
 
+// preparedQueries are the fixed statements Prepare prepares, queries built per call are run as is
+var preparedQueries = []string{
+	createEntity, updateEntityName, updateEntitySlug, deleteEntity,
+	getEntityByID, getEntityBySlug, getEntityByIDWithAttributes, getEntityBySlugWithAttributes,
+	listEntitiesNoAttributes, listEntitiesWithAttributes, loadAttributesByEntity,
+	createAttribute, updateAttributeName, updateAttributeSlug, updateAttributeType, deleteAttribute,
+	getAttributeByID, getAttributeBySlug, getAttributeByIDWithEntities, getAttributeBySlugWithEntities,
+	listAttributesNoEntities, listAttributesWithEntities, loadEntitiesByAttribute,
+	createEntityAttribute, deleteEntityAttribute, updatedRequireEntityAttribute,
+	deleteEntityAttributeByAttribute, deleteEntityAttributeByEntity,
+	createItem, updateItemEntityID, deleteItem, getItem, listItems,
+	listItemAttributesByItem, listItemValuesByItem, listAllItemValues, listEntityItemValues,
+	createItemAttribute, updateItemAttribute, getItemValue, setItemValue,
+	deleteItemAttribute, deleteItemAttributesByItem, deleteItemAttributesByEntityAttribute,
+	deleteItemAttributesByEntity, deleteItemsByEntity, deleteItemAttributesByAttribute,
+	listEntityViews, listStoredValues, updateStoredValue,
+	createTenant, updateTenantName, updateTenantSlug, deleteTenant, getTenantByID, getTenantBySlug, listTenants,
+}
+
+// Prepare returns Queries that run the fixed statements prepared once on db instead of on every call,
+// Close releases them once the Queries are no longer used.
+// WithTx rebinds them to the transaction, q.RunInTx prepares them again on the connection of its transaction
+// the first time they run in it
+func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
+	q := Queries{db: db, stmts: make(map[string]*sql.Stmt, len(preparedQueries))}
+
+	for _, query := range preparedQueries {
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			q.Close()
+			return nil, fmt.Errorf("Failed to prepare %s: %w", strings.TrimSpace(query), err)
+		}
+
+		q.stmts[query] = stmt
+	}
+
+	return &q, nil
+}
+
+// Close releases the statements prepared by Prepare, Queries made by New have none to release
+func (q *Queries) Close() error {
+	var errs []error
+	for _, stmt := range q.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stmt returns the statement prepared for query bound to the transaction of q, nil when query was not prepared
+func (q *Queries) stmt(ctx context.Context, query string) *sql.Stmt {
+	stmt, ok := q.stmts[query]
+	if !ok {
+		return nil
+	}
+
+	if q.connStmts != nil {
+		if stmt, ok := q.connStmts[query]; ok {
+			return stmt
+		}
+
+		// A failed prepare runs query unprepared, which fails with the same error where it is handled
+		stmt, err := q.db.PrepareContext(ctx, query)
+		if err != nil {
+			return nil
+		}
+
+		q.connStmts[query] = stmt
+		return stmt
+	}
+
+	if q.tx != nil {
+		return q.tx.StmtContext(ctx, stmt)
+	}
+
+	return stmt
+}
+
+func (q *Queries) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt := q.stmt(ctx, query); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+
+	return q.db.ExecContext(ctx, query, args...)
+}
+
+func (q *Queries) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if stmt := q.stmt(ctx, query); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+
+	return q.db.QueryContext(ctx, query, args...)
+}
+
+func (q *Queries) queryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if stmt := q.stmt(ctx, query); stmt != nil {
+		return stmt.QueryRowContext(ctx, args...)
+	}
+
+	return q.db.QueryRowContext(ctx, query, args...)
+}
+
 type GetType string
 const (
 	BySlug GetType = "slug"
@@ -0,0 +1,265 @@
+package geaves
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Bases of the parameterized attribute types, written with their precision and scale like decimal(10,2),
+// see NewDecimalType and NewMoneyType
+const (
+	DecimalType AttributeType = "decimal"
+	MoneyType AttributeType = "money"
+)
+
+// MaxDecimalPrecision is the most digits a decimal or money type can have, their unscaled value is an int64
+const MaxDecimalPrecision = 18
+
+// NewDecimalType returns the type of decimals with precision digits, scale of which after the point,
+// decimal(10,2) holds up to 99999999.99
+func NewDecimalType(precision int, scale int) AttributeType {
+	return AttributeType(fmt.Sprintf("%s(%d,%d)", DecimalType, precision, scale))
+}
+
+// NewMoneyType returns the type of amounts of money with precision digits, scale of which after the point,
+// each paired with its currency
+func NewMoneyType(precision int, scale int) AttributeType {
+	return AttributeType(fmt.Sprintf("%s(%d,%d)", MoneyType, precision, scale))
+}
+
+// Base returns t without its parameters, decimal for decimal(10,2), types without parameters are their own base
+func (t AttributeType) Base() AttributeType {
+	if idx := strings.IndexByte(string(t), '('); idx >= 0 {
+		return t[:idx]
+	}
+
+	return t
+}
+
+// DecimalParams returns the precision and scale of a decimal or money type, ok is false for other types and
+// for parameters out of range, which are only valid written the way NewDecimalType writes them
+func (t AttributeType) DecimalParams() (precision int, scale int, ok bool) {
+	base := t.Base()
+	if base != DecimalType && base != MoneyType {
+		return 0, 0, false
+	}
+
+	params, found := strings.CutPrefix(string(t), string(base) + "(")
+	if !found {
+		return 0, 0, false
+	}
+
+	params, found = strings.CutSuffix(params, ")")
+	if !found {
+		return 0, 0, false
+	}
+
+	p, s, found := strings.Cut(params, ",")
+	if !found {
+		return 0, 0, false
+	}
+
+	precision, err := strconv.Atoi(p)
+	if err != nil || strconv.Itoa(precision) != p {
+		return 0, 0, false
+	}
+
+	scale, err = strconv.Atoi(s)
+	if err != nil || strconv.Itoa(scale) != s {
+		return 0, 0, false
+	}
+
+	if precision < 1 || precision > MaxDecimalPrecision || scale < 0 || scale > precision {
+		return 0, 0, false
+	}
+
+	return precision, scale, true
+}
+
+// Decimal is an exact decimal number, Unscaled divided by 10 to the power of Scale, 12.30 is Decimal{1230, 2}
+type Decimal struct {
+	Unscaled int64
+	Scale int
+}
+
+// ParseDecimal reads a number written in base 10 with an optional sign and '.' for decimals, like -12.30,
+// its scale is the number of digits after the point
+func ParseDecimal(s string) (Decimal, error) {
+	digits := s
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		digits, negative = s[1:], true
+	case strings.HasPrefix(s, "+"):
+		digits = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole == "" && fraction == "" {
+		return Decimal{}, fmt.Errorf("'%s' is not a decimal", s)
+	}
+
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return Decimal{}, fmt.Errorf("'%s' is not a decimal", s)
+		}
+	}
+
+	unscaled, err := strconv.ParseInt(whole + fraction, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("'%s' has too many digits for a decimal", s)
+	}
+
+	if negative {
+		unscaled = -unscaled
+	}
+
+	return Decimal{unscaled, len(fraction)}, nil
+}
+
+// String writes d with Scale digits after the point, in the format read by ParseDecimal
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.Unscaled, 10)
+	if d.Scale <= 0 {
+		return s
+	}
+
+	sign := ""
+	if d.Unscaled < 0 {
+		sign, s = "-", s[1:]
+	}
+
+	if len(s) <= d.Scale {
+		s = strings.Repeat("0", d.Scale - len(s) + 1) + s
+	}
+
+	return sign + s[:len(s) - d.Scale] + "." + s[len(s) - d.Scale:]
+}
+
+// Rescale returns d with scale digits after the point, only digits that are zero can be dropped
+func (d Decimal) Rescale(scale int) (Decimal, error) {
+	unscaled := d.Unscaled
+	for ; d.Scale < scale; d.Scale++ {
+		if unscaled > math.MaxInt64 / 10 || unscaled < math.MinInt64 / 10 {
+			return d, fmt.Errorf("%s has too many digits for scale %v", d, scale)
+		}
+		unscaled *= 10
+	}
+
+	for ; d.Scale > scale; d.Scale-- {
+		if unscaled % 10 != 0 {
+			return d, fmt.Errorf("%s has more than %v digits after the point", d, scale)
+		}
+		unscaled /= 10
+	}
+
+	return Decimal{unscaled, scale}, nil
+}
+
+// Money is an amount in the currency with the ISO 4217 code Currency, like EUR
+type Money struct {
+	Amount Decimal
+	Currency string
+}
+
+// ParseMoney reads an amount followed by its currency code, like 12.30 EUR
+func ParseMoney(s string) (Money, error) {
+	amount, currency, found := strings.Cut(s, " ")
+	if !found {
+		return Money{}, fmt.Errorf("'%s' is not an amount followed by a currency", s)
+	}
+
+	if !validCurrency(currency) {
+		return Money{}, fmt.Errorf("'%s' is not an ISO 4217 currency code", currency)
+	}
+
+	d, err := ParseDecimal(amount)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{d, currency}, nil
+}
+
+// String writes m in the format read by ParseMoney
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
+
+// validCurrency reports whether code is written like an ISO 4217 code, three upper case letters
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Money is stored as its currency followed by its unscaled amount offset to be positive and padded, which sorts
+// amounts of the same currency in order as text, the currency keeps the value column from reading it as a number
+const (
+	storedMoneyOffset = 1_000_000_000_000_000_000
+	storedMoneyWidth = 19
+)
+
+// encodeDecimal returns the unscaled value of d at the scale of t, as long as it fits the precision of t
+func encodeDecimal(t AttributeType, d Decimal) (int64, error) {
+	precision, scale, ok := t.DecimalParams()
+	if !ok {
+		return 0, fmt.Errorf("'%s' is not a valid attribute type", t)
+	}
+
+	scaled, err := d.Rescale(scale)
+	if err != nil {
+		return 0, err
+	}
+
+	limit := int64(math.Pow10(precision))
+	if scaled.Unscaled >= limit || scaled.Unscaled <= -limit {
+		return 0, fmt.Errorf("%s has more than %v digits", d, precision)
+	}
+
+	return scaled.Unscaled, nil
+}
+
+// scaleDecimal returns d at the scale of t, as long as it fits the precision of t
+func scaleDecimal(t AttributeType, d Decimal) (Decimal, error) {
+	_, scale, _ := t.DecimalParams()
+	unscaled, err := encodeDecimal(t, d)
+	return Decimal{unscaled, scale}, err
+}
+
+func encodeMoney(t AttributeType, m Money) (string, error) {
+	if !validCurrency(m.Currency) {
+		return "", fmt.Errorf("'%s' is not an ISO 4217 currency code", m.Currency)
+	}
+
+	unscaled, err := encodeDecimal(t, m.Amount)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%0*d", m.Currency, storedMoneyWidth, unscaled + storedMoneyOffset), nil
+}
+
+func decodeMoney(t AttributeType, s string) (Money, error) {
+	_, scale, _ := t.DecimalParams()
+	if len(s) != 3 + storedMoneyWidth || !validCurrency(s[:3]) {
+		return Money{}, fmt.Errorf("stored '%s' can not be read as %s", s, t)
+	}
+
+	offset, err := strconv.ParseInt(s[3:], 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("stored '%s' can not be read as %s", s, t)
+	}
+
+	return Money{Decimal{offset - storedMoneyOffset, scale}, s[:3]}, nil
+}
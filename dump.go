@@ -0,0 +1,278 @@
+package geaves
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DumpVersion is the version of the dump format written by WriteDump, ReadDump refuses any other version
+const DumpVersion = 1
+
+// Dump is a portable copy of everything in a geaves database, entities, attributes and
+// links refer to each other by slug so a dump can be imported into a database with other IDs
+type Dump struct {
+	Version int `json:"version"`
+	Entities []DumpEntity `json:"entities"`
+	Attributes []DumpAttribute `json:"attributes"`
+	Links []DumpLink `json:"links"`
+	Items []DumpItem `json:"items"`
+}
+
+type DumpEntity struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type DumpAttribute struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type AttributeType `json:"type"`
+}
+
+type DumpLink struct {
+	Entity string `json:"entity"`
+	Attribute string `json:"attribute"`
+	Required bool `json:"required"`
+}
+
+// DumpItem holds the values of an item by attribute slug, as written by Value.MarshalJSON
+type DumpItem struct {
+	ID int64 `json:"id"`
+	Entity string `json:"entity"`
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+func ExportDump(ctx context.Context, q *Queries) (Dump, error) {
+	d := Dump{Version: DumpVersion}
+
+	entities, err := q.ListEntities(ctx, false)
+	if err != nil {
+		return d, fmt.Errorf("Failed to list entities: %w", err)
+	}
+
+	entitySlugs := make(map[int64]string, len(entities))
+	for _, entity := range entities {
+		entitySlugs[entity.ID] = entity.Slug
+		d.Entities = append(d.Entities, DumpEntity{entity.ID, entity.Name, entity.Slug})
+
+		attributes, err := entity.GetAttributes(ctx, q)
+		if err != nil {
+			return d, fmt.Errorf("Failed to get attributes of %s: %w", entity.Slug, err)
+		}
+
+		for _, attribute := range attributes {
+			d.Links = append(d.Links, DumpLink{entity.Slug, attribute.Slug, attribute.Required})
+		}
+	}
+
+	attributes, err := q.ListAttributes(ctx, false)
+	if err != nil {
+		return d, fmt.Errorf("Failed to list attributes: %w", err)
+	}
+
+	for _, attribute := range attributes {
+		d.Attributes = append(d.Attributes, DumpAttribute{attribute.ID, attribute.Name, attribute.Slug, attribute.Type})
+	}
+
+	items, err := q.ListItems(ctx)
+	if err != nil {
+		return d, fmt.Errorf("Failed to list items: %w", err)
+	}
+
+	values, err := q.ListAllItemValues(ctx)
+	if err != nil {
+		return d, fmt.Errorf("Failed to list item values: %w", err)
+	}
+
+	itemIdx := make(map[int64]int, len(items))
+	for _, item := range items {
+		itemIdx[item.ID] = len(d.Items)
+		d.Items = append(d.Items, DumpItem{
+			ID: item.ID,
+			Entity: entitySlugs[item.EntityID],
+			Values: map[string]json.RawMessage{},
+		})
+	}
+
+	for _, value := range values {
+		idx, ok := itemIdx[value.ItemID]
+		if !ok {
+			continue
+		}
+
+		data, err := json.Marshal(value.Value)
+		if err != nil {
+			return d, fmt.Errorf("Failed to encode %s on item %v: %w", value.Slug, value.ItemID, err)
+		}
+
+		d.Items[idx].Values[value.Slug] = data
+	}
+
+	return d, nil
+}
+
+func WriteDump(w io.Writer, d Dump) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+func ReadDump(r io.Reader) (Dump, error) {
+	var d Dump
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return d, fmt.Errorf("Failed to read dump: %w", err)
+	}
+
+	if d.Version != DumpVersion {
+		return d, fmt.Errorf("Unsupported dump version %v, expected %v", d.Version, DumpVersion)
+	}
+
+	return d, nil
+}
+
+type ImportResult struct {
+	EntitiesCreated int
+	AttributesCreated int
+	LinksCreated int
+	LinksUpdated int
+	ItemsCreated int
+	ValuesSet int
+	// ItemIDs maps item IDs in the dump to the IDs they were created with
+	ItemIDs map[int64]int64
+}
+
+// ImportDump adds everything in d to the database, entities and attributes that already exist
+// (by slug) are reused and every item is created anew. The import runs in one transaction (see RunInTx),
+// a failing import adds nothing
+func ImportDump(ctx context.Context, q *Queries, d Dump) (ImportResult, error) {
+	var res ImportResult
+	err := q.RunInTx(ctx, func(q *Queries) error {
+		var err error
+		res, err = importDump(ctx, q, d)
+		return err
+	})
+
+	return res, err
+}
+
+func importDump(ctx context.Context, q *Queries, d Dump) (ImportResult, error) {
+	res := ImportResult{ItemIDs: map[int64]int64{}}
+
+	entityIDs := make(map[string]int64, len(d.Entities))
+	for _, dumped := range d.Entities {
+		entity, err := q.GetEntity(ctx, GetEntityParam{Field: BySlug, Value: dumped.Slug})
+		if errors.Is(err, sql.ErrNoRows) {
+			entity, err = q.CreateEntity(ctx, CreateEntityParam{dumped.Name, dumped.Slug})
+			res.EntitiesCreated++
+		}
+		if err != nil {
+			return res, fmt.Errorf("Failed to import entity %s: %w", dumped.Slug, err)
+		}
+
+		entityIDs[dumped.Slug] = entity.ID
+	}
+
+	attributes := make(map[string]Attribute, len(d.Attributes))
+	for _, dumped := range d.Attributes {
+		if !ValidAttributeType(string(dumped.Type)) {
+			return res, fmt.Errorf("Attribute %s has invalid type '%s'", dumped.Slug, dumped.Type)
+		}
+
+		attribute, err := q.GetAttribute(ctx, GetAttributeParam{Field: BySlug, Value: dumped.Slug})
+		if errors.Is(err, sql.ErrNoRows) {
+			attribute, err = q.CreateAttribute(ctx, CreateAttributeParam{dumped.Name, dumped.Slug, dumped.Type})
+			res.AttributesCreated++
+		}
+		if err != nil {
+			return res, fmt.Errorf("Failed to import attribute %s: %w", dumped.Slug, err)
+		}
+
+		if attribute.Type != dumped.Type {
+			return res, fmt.Errorf("Attribute %s already exists as %s, the dump has it as %s", dumped.Slug, attribute.Type, dumped.Type)
+		}
+
+		attributes[dumped.Slug] = attribute
+	}
+
+	linked := map[int64]map[int64]bool{}
+	for _, link := range d.Links {
+		entityID, ok := entityIDs[link.Entity]
+		if !ok {
+			return res, fmt.Errorf("Link refers to entity %s which is not in the dump", link.Entity)
+		}
+
+		attribute, ok := attributes[link.Attribute]
+		if !ok {
+			return res, fmt.Errorf("Link refers to attribute %s which is not in the dump", link.Attribute)
+		}
+
+		if _, ok := linked[entityID]; !ok {
+			existing, err := q.LoadAttributesByEntity(ctx, entityID)
+			if err != nil {
+				return res, fmt.Errorf("Failed to get attributes of %s: %w", link.Entity, err)
+			}
+
+			linked[entityID] = map[int64]bool{}
+			for _, attr := range existing {
+				linked[entityID][attr.ID] = attr.Required
+			}
+		}
+
+		required, exists := linked[entityID][attribute.ID]
+		switch {
+		case !exists:
+			_, err := q.CreateEntityAttribute(ctx, EntityAttribute{entityID, attribute.ID, link.Required})
+			if err != nil {
+				return res, fmt.Errorf("Failed to link %s to %s: %w", link.Attribute, link.Entity, err)
+			}
+			res.LinksCreated++
+		case required != link.Required:
+			err := q.UpdateRequireEntityAttribute(ctx, link.Required, entityID, attribute.ID)
+			if err != nil {
+				return res, fmt.Errorf("Failed to update link of %s to %s: %w", link.Attribute, link.Entity, err)
+			}
+			res.LinksUpdated++
+		}
+	}
+
+	for _, dumped := range d.Items {
+		entityID, ok := entityIDs[dumped.Entity]
+		if !ok {
+			return res, fmt.Errorf("Item %v refers to entity %s which is not in the dump", dumped.ID, dumped.Entity)
+		}
+
+		item, err := q.CreateItem(ctx, entityID)
+		if err != nil {
+			return res, fmt.Errorf("Failed to import item %v: %w", dumped.ID, err)
+		}
+
+		res.ItemsCreated++
+		res.ItemIDs[dumped.ID] = item.ID
+
+		for slug, data := range dumped.Values {
+			attribute, ok := attributes[slug]
+			if !ok {
+				return res, fmt.Errorf("Item %v has a value for attribute %s which is not in the dump", dumped.ID, slug)
+			}
+
+			value, err := DecodeJSONValue(attribute.Type, data)
+			if err != nil {
+				return res, fmt.Errorf("Invalid %s on item %v: %w", slug, dumped.ID, err)
+			}
+
+			if err := q.SetItemValue(ctx, item.ID, attribute.ID, value); err != nil {
+				return res, fmt.Errorf("Failed to set %s on item %v: %w", slug, dumped.ID, err)
+			}
+
+			res.ValuesSet++
+		}
+	}
+
+	return res, nil
+}
@@ -33,6 +33,17 @@ func (e *Entity) GetAttributes(ctx context.Context, q *Queries) ([]EntityAttribu
 	return e.attributes, err
 }
 
+// attribute returns the attribute with slug among the loaded attributes of e
+func (e *Entity) attribute(slug string) (EntityAttributeEmbed, bool) {
+	for _, attribute := range e.attributes {
+		if attribute.Slug == slug {
+			return attribute, true
+		}
+	}
+
+	return EntityAttributeEmbed{}, false
+}
+
 type EntityOption func(*Entity)
 
 func WithAttribute(attr *Attribute) EntityOption {
@@ -121,8 +132,8 @@ func (e *Entity) Save(q *Queries, ctx context.Context) error {
 }
 
 const createEntity = `
-INSERT INTO entities (name, slug) VALUES (?, ?)
-RETURNING *;
+INSERT INTO entities (name, slug, tenant_id) VALUES (?, ?, ?)
+RETURNING id, name, slug;
 `
 
 type CreateEntityParam struct {
@@ -131,9 +142,10 @@ type CreateEntityParam struct {
 }
 
 func (q *Queries) CreateEntity(ctx context.Context, arg CreateEntityParam) (Entity, error) {
-	row := q.db.QueryRowContext(ctx, createEntity,
+	row := q.queryRowContext(ctx, createEntity,
 		arg.Name,
 		arg.Slug,
+		q.tenant,
 	)
 
 	var i Entity
@@ -142,39 +154,47 @@ func (q *Queries) CreateEntity(ctx context.Context, arg CreateEntityParam) (Enti
 		&i.Name,
 		&i.Slug,
 	)
+	if err != nil {
+		return i, classify(err)
+	}
 
-	return i, err
+	return i, q.refreshViews(ctx)
 }
 
 const updateEntityName = `
-UPDATE entities SET name = ? WHERE id = ?;
+UPDATE entities SET name = ? WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) UpdateEntityName(ctx context.Context, name string, id int64) error {
-	_, err := q.db.ExecContext(ctx, updateEntityName, name, id)
-	return err
+	return q.execOne(ctx, updateEntityName, name, id, q.tenant)
 }
 
 const updateEntitySlug = `
-UPDATE entities SET slug = ? WHERE id = ?;
+UPDATE entities SET slug = ? WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) UpdateEntitySlug(ctx context.Context, slug string, id int64) error {
-	_, err := q.db.ExecContext(ctx, updateEntitySlug, slug, id)
-	return err
+	if err := q.execOne(ctx, updateEntitySlug, slug, id, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
 
 const deleteEntity = `
-DELETE FROM entities WHERE id = ?;
+DELETE FROM entities WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) DeleteEntity(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteEntity, id)
-	return err
+	if err := q.execOne(ctx, deleteEntity, id, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
 
 const getEntityNoAttributes = `
-SELECT id, name, slug, null FROM entities WHERE %s = ?;
+SELECT id, name, slug, null FROM entities WHERE %s = ? AND tenant_id = ?;
 `
 
 const getEntityWithAttributes = `
@@ -189,10 +209,19 @@ SELECT
   NULL) AS attributes
 FROM entities
 LEFT JOIN entity_attribute ON entity_attribute.entity_id = entities.id
-INNER JOIN attributes ON entity_attribute.attribute_id = attributes.id
-WHERE %s = ?;
+LEFT JOIN attributes ON entity_attribute.attribute_id = attributes.id
+WHERE %s = ? AND entities.tenant_id = ?
+GROUP BY entities.id;
 `
 
+// Variants of the get entity queries by GetType, distinct statements so each can be prepared
+var (
+	getEntityByID = fmt.Sprintf(getEntityNoAttributes, "entities.id")
+	getEntityBySlug = fmt.Sprintf(getEntityNoAttributes, "entities.slug")
+	getEntityByIDWithAttributes = fmt.Sprintf(getEntityWithAttributes, "entities.id")
+	getEntityBySlugWithAttributes = fmt.Sprintf(getEntityWithAttributes, "entities.slug")
+)
+
 type GetEntityParam struct {
 	WithAttributes bool
 	Field GetType
@@ -200,33 +229,31 @@ type GetEntityParam struct {
 }
 
 func (q *Queries) GetEntity(ctx context.Context, arg GetEntityParam) (Entity, error) {
+	var query string
 	switch arg.Field {
 	case ByID:
 		if _, ok := arg.Value.(int64); !ok {
-			return Entity{}, errors.New("GetEntityParam's value has wrong type")
+			return Entity{}, &ValidationError{Field: "GetEntityParam.Value", Value: arg.Value, Err: ErrInvalidType}
+		}
+		query = getEntityByID
+		if arg.WithAttributes {
+			query = getEntityByIDWithAttributes
 		}
-		arg.Value = arg.Value.(int64)
-		arg.Field = "entities.id"
 		break;
 	case BySlug:
 		if _, ok := arg.Value.(string); !ok {
-			return Entity{}, errors.New("GetEntityParam's vlaue has wrong type")
+			return Entity{}, &ValidationError{Field: "GetEntityParam.Value", Value: arg.Value, Err: ErrInvalidType}
+		}
+		query = getEntityBySlug
+		if arg.WithAttributes {
+			query = getEntityBySlugWithAttributes
 		}
-		arg.Value = arg.Value.(string)
-		arg.Field = "entities.slug"
 		break;
 	default:
-		return Entity{}, fmt.Errorf("'%s' unsupported field to look for entity", arg.Field)
+		return Entity{}, &ValidationError{Field: "GetEntityParam.Field", Value: arg.Field, Err: ErrInvalidType}
 	}
 
-	var query string
-	if (arg.WithAttributes) {
-		query = getEntityWithAttributes
-	} else {
-		query = getEntityNoAttributes
-	}
-
-	row := q.db.QueryRowContext(ctx, fmt.Sprintf(query, arg.Field), arg.Value)
+	row := q.queryRowContext(ctx, query, arg.Value, q.tenant)
 
 	var i Entity
 	var attributesJson *string
@@ -236,7 +263,7 @@ func (q *Queries) GetEntity(ctx context.Context, arg GetEntityParam) (Entity, er
 		&i.Slug,
 	 	&attributesJson,
 	); err != nil {
-		return i, err
+		return i, classify(err)
 	}
 
 	if arg.WithAttributes {
@@ -256,7 +283,7 @@ func (q *Queries) GetEntity(ctx context.Context, arg GetEntityParam) (Entity, er
 }
 
 const listEntitiesNoAttributes = `
-SELECT id, name, slug, null FROM entities;
+SELECT id, name, slug, null FROM entities WHERE tenant_id = ?;
 `
 
 const listEntitiesWithAttributes = `
@@ -271,7 +298,8 @@ SELECT
   NULL) AS attributes
 FROM entities
 LEFT JOIN entity_attribute ON entity_attribute.entity_id = entities.id
-INNER JOIN attributes ON entity_attribute.attribute_id = attributes.id
+LEFT JOIN attributes ON entity_attribute.attribute_id = attributes.id
+WHERE entities.tenant_id = ?
 GROUP BY entities.id;
 `
 
@@ -283,9 +311,9 @@ func (q *Queries) ListEntities(ctx context.Context, withAttributes bool) ([]Enti
 		query = listEntitiesNoAttributes
 	}
 
-	rows, err := q.db.QueryContext(ctx, query)
+	rows, err := q.queryContext(ctx, query, q.tenant)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 	defer rows.Close()
 
@@ -300,7 +328,7 @@ func (q *Queries) ListEntities(ctx context.Context, withAttributes bool) ([]Enti
 			&i.Slug,
 			&attributesJson,
 		); err != nil {
-			return items, err
+			return items, classify(err)
 		}
 
 		if withAttributes {
@@ -319,7 +347,7 @@ func (q *Queries) ListEntities(ctx context.Context, withAttributes bool) ([]Enti
 		items = append(items, i)
 	}
 
-	return items, nil
+	return items, classify(rows.Err())
 }
 
 const loadAttributesByEntity = `
@@ -330,18 +358,18 @@ SELECT IIF(entity_attribute.entity_id IS NOT NULL,
   NULL) AS attributes
 FROM entities
 LEFT JOIN entity_attribute ON entity_attribute.entity_id = entities.id
-INNER JOIN attributes ON entity_attribute.attribute_id = attributes.id
-WHERE entities.id = ?;
+LEFT JOIN attributes ON entity_attribute.attribute_id = attributes.id
+WHERE entities.id = ? AND entities.tenant_id = ?;
 `
 
 func (q *Queries) LoadAttributesByEntity(ctx context.Context, id int64) ([]EntityAttributeEmbed, error) {
-	row := q.db.QueryRowContext(ctx, loadAttributesByEntity, id)
+	row := q.queryRowContext(ctx, loadAttributesByEntity, id, q.tenant)
 
 	var attributesJson *string
 	if err := row.Scan(
 		&attributesJson,
 	); err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 
 	if attributesJson == nil {
@@ -369,15 +397,15 @@ func parseAttributesJson(attributesJson string) ([]EntityAttributeEmbed, error)
 		return nil, err
 	}
 
-	attributes := make([]EntityAttributeEmbed, len(parsedAttributes))
-	for idx, parsedAttribute := range parsedAttributes {
+	attributes := make([]EntityAttributeEmbed, 0, len(parsedAttributes))
+	for _, parsedAttribute := range parsedAttributes {
 		// TODO log this
 		if !ValidAttributeType(parsedAttribute.Type) {
 			fmt.Fprintf(os.Stderr, "Invalid type %s, skipping attribute %s\n", parsedAttribute.Type, parsedAttribute.Name)
 			continue
 		}
 
-		attributes[idx] = EntityAttributeEmbed{
+		attributes = append(attributes, EntityAttributeEmbed{
 			Attribute: Attribute{
 				ID: parsedAttribute.ID,
 				Name: parsedAttribute.Name,
@@ -385,7 +413,7 @@ func parseAttributesJson(attributesJson string) ([]EntityAttributeEmbed, error)
 				Type: AttributeType(parsedAttribute.Type),
 			},
 			Required: parsedAttribute.Required > 0,
-		}
+		})
 	}
 
 	return attributes, nil
@@ -11,15 +11,17 @@ type EntityAttribute struct {
 }
 
 const createEntityAttribute = `
-INSERT INTO entity_attribute (entity_id, attribute_id, required) VALUES (?, ?, ?)
-RETURNING *;
+INSERT INTO entity_attribute (entity_id, attribute_id, required)
+SELECT id, ?, ? FROM entities WHERE id = ? AND tenant_id = ?
+RETURNING entity_id, attribute_id, required;
 `
 
 func (q *Queries) CreateEntityAttribute(ctx context.Context, arg EntityAttribute) (EntityAttribute, error) {
-	row := q.db.QueryRowContext(ctx, createEntityAttribute,
-		arg.EntityID,
+	row := q.queryRowContext(ctx, createEntityAttribute,
 		arg.AttributeID,
 		arg.Required,
+		arg.EntityID,
+		q.tenant,
 	)
 
 	var i EntityAttribute
@@ -28,42 +30,53 @@ func (q *Queries) CreateEntityAttribute(ctx context.Context, arg EntityAttribute
 		&i.AttributeID,
 		&i.Required,
 	)
+	if err != nil {
+		return i, classify(err)
+	}
 
-	return i, err
+	return i, q.refreshViews(ctx)
 }
 
 const deleteEntityAttribute = `
-DELETE FROM entity_attribute WHERE entity_id = ? AND attribute_id = ?;
+DELETE FROM entity_attribute WHERE entity_id = ? AND attribute_id = ? AND entity_id IN (SELECT id FROM entities WHERE tenant_id = ?);
 `
 
 func (q *Queries) DeleteEntityAttribute(ctx context.Context, entityId int64, attributeId int64) error {
-	_, err := q.db.ExecContext(ctx, deleteEntityAttribute, entityId, attributeId)
-	return err
+	if err := q.execOne(ctx, deleteEntityAttribute, entityId, attributeId, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
 
 const updatedRequireEntityAttribute = `
-UPDATE entity_attribute SET required = ? WHERE entity_id = ? AND attribute_id = ?;
+UPDATE entity_attribute SET required = ? WHERE entity_id = ? AND attribute_id = ? AND entity_id IN (SELECT id FROM entities WHERE tenant_id = ?);
 `
 
 func (q *Queries) UpdateRequireEntityAttribute(ctx context.Context, req bool, entityId int64, attributeId int64) error {
-	_, err := q.db.ExecContext(ctx, updatedRequireEntityAttribute, req, entityId, attributeId)
-	return err
+	return q.execOne(ctx, updatedRequireEntityAttribute, req, entityId, attributeId, q.tenant)
 }
 
 const deleteEntityAttributeByAttribute = `
-DELETE FROM entity_attribute WHERE attribute_id = ?
+DELETE FROM entity_attribute WHERE attribute_id = ? AND entity_id IN (SELECT id FROM entities WHERE tenant_id = ?)
 `
 
 func (q *Queries) DeleteEntityAttributeByAttribute(ctx context.Context, attributeId int64) error {
-	_, err := q.db.ExecContext(ctx, deleteEntityAttributeByAttribute, attributeId)
-	return err
+	if err := q.exec(ctx, deleteEntityAttributeByAttribute, attributeId, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
 
 const deleteEntityAttributeByEntity = `
-DELETE FROM entity_attribute WHERE entity_id = ?
+DELETE FROM entity_attribute WHERE entity_id = ? AND entity_id IN (SELECT id FROM entities WHERE tenant_id = ?)
 `
 
 func (q *Queries) DeleteEntityAttributeByEntity(ctx context.Context, entityId int64) error {
-	_, err := q.db.ExecContext(ctx, deleteEntityAttributeByEntity, entityId)
-	return err
+	if err := q.exec(ctx, deleteEntityAttributeByEntity, entityId, q.tenant); err != nil {
+		return err
+	}
+
+	return q.refreshViews(ctx)
 }
@@ -0,0 +1,118 @@
+package geaves
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by Queries methods, wrapped around the error of the driver so both can be matched with errors.Is
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is a unique constraint failing, ErrDuplicateSlug and ErrDuplicateName are also ErrDuplicate
+	ErrDuplicate = errors.New("already exists")
+	ErrDuplicateSlug = fmt.Errorf("slug %w", ErrDuplicate)
+	ErrDuplicateName = fmt.Errorf("name %w", ErrDuplicate)
+	ErrInvalidType = errors.New("invalid type")
+	ErrRequiredMissing = errors.New("required attribute missing")
+	// ErrForeignKey is a row referring to an entity, attribute or item that does not exist or belongs to another tenant,
+	// or one being deleted while still referred to
+	ErrForeignKey = errors.New("foreign key constraint failed")
+	// ErrMixedCurrencies is an aggregate over money values in more than one currency
+	ErrMixedCurrencies = errors.New("mixed currencies")
+)
+
+// ValidationError is an argument refused by a Queries method, Err is the sentinel saying why
+type ValidationError struct {
+	// Field is what was refused, like type or the slug of an attribute
+	Field string
+	// Value is what was given for Field, nil when nothing was
+	Value any
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("%s: %s", e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("%s %v: %s", e.Field, e.Value, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// sqlite extended result codes of the constraints geaves tables have
+const (
+	sqliteConstraintCheck = 275
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintTrigger = 1811
+	sqliteConstraintUnique = 2067
+)
+
+// classify wraps err with the sentinel it matches, keeping err in the chain, errors that match none are returned as is
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var coded interface{ Code() int }
+	if !errors.As(err, &coded) {
+		return err
+	}
+
+	switch coded.Code() {
+	case sqliteConstraintUnique, sqliteConstraintPrimaryKey:
+		// The message names the columns, like UNIQUE constraint failed: entities.slug
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, ".slug"):
+			return fmt.Errorf("%w: %w", ErrDuplicateSlug, err)
+		case strings.Contains(msg, ".name"):
+			return fmt.Errorf("%w: %w", ErrDuplicateName, err)
+		default:
+			return fmt.Errorf("%w: %w", ErrDuplicate, err)
+		}
+	case sqliteConstraintForeignKey, sqliteConstraintTrigger:
+		// The only triggers refuse links between tenants, which is a foreign key of another tenant
+		return fmt.Errorf("%w: %w", ErrForeignKey, err)
+	case sqliteConstraintCheck:
+		// The only check is the type of attributes
+		return fmt.Errorf("%w: %w", ErrInvalidType, err)
+	}
+
+	return err
+}
+
+// execOne runs a statement meant for one row, returning ErrNotFound when it matched none
+func (q *Queries) execOne(ctx context.Context, query string, args ...any) error {
+	res, err := q.execContext(ctx, query, args...)
+	if err != nil {
+		return classify(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %w", ErrNotFound, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// exec runs a statement on any number of rows
+func (q *Queries) exec(ctx context.Context, query string, args ...any) error {
+	_, err := q.execContext(ctx, query, args...)
+	return classify(err)
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
-	"os"
 
 	"github.com/Asfolny/geaves"
 )
@@ -23,7 +23,7 @@ func attributeCommand(s state) error {
 		return fmt.Errorf("%s: attribute command not found\n", s.args[0])
 	}
 
-	return  cmd.callback(state{s.args[0], s.args[1:], s.queries})
+	return  cmd.callback(state{s.args[0], s.args[1:], s.queries, s.db, s.output, s.tenant})
 }
 
 func getAttributeCommands() map[string]command {
@@ -62,7 +62,7 @@ func getAttributeCommands() map[string]command {
 }
 
 func createAttributeCommand(s state) error {
-	registerFs := flag.NewFlagSet("attribute", flag.ExitOnError)
+	registerFs := flag.NewFlagSet("attribute", flag.ContinueOnError)
 
 	var name string
 	var slug string
@@ -77,19 +77,18 @@ func createAttributeCommand(s state) error {
 	registerFs.StringVar(&typeString, "type", "", "Type of new attribute")
 	registerFs.StringVar(&typeString, "t", "", "Type of new attribute (shorthand)")
 
-	registerFs.Parse(s.args)
+	if err := registerFs.Parse(s.args); err != nil {
+		return err
+	}
 
 	if name == "" || slug == "" || typeString == "" {
-		fmt.Fprintln(os.Stderr, "Name, slug and type must be provided, but one or more was empty")
 		// TODO print usage
-		os.Exit(1)
+		return fmt.Errorf("Name, slug and type must be provided, but one or more was empty")
 	}
 
 	if !geaves.ValidAttributeType(typeString) {
-		fmt.Fprintln(os.Stderr, "type provided was not valid, please use a valid type")
 		// TODO print usage with types
-		// TODO print valid types
-		os.Exit(1)
+		return &geaves.ValidationError{Field: "type", Value: typeString, Err: geaves.ErrInvalidType}
 	}
 
 	attribute, err := s.queries.CreateAttribute(context.Background(), geaves.CreateAttributeParam{Name: name, Slug: slug, Type: geaves.AttributeType(typeString)})
@@ -97,25 +96,43 @@ func createAttributeCommand(s state) error {
 		return err
 	}
 
+	if s.output != outputText {
+		return writeAttribute(s, attribute)
+	}
+
 	fmt.Printf("Successfully created %s (%s), of type %s\n", attribute.Name, attribute.Slug, attribute.Type)
 	return nil
 }
 
 func listAttributesCommand(s state) error {
- 	listFs := flag.NewFlagSet("attribute", flag.ExitOnError)
+ 	listFs := flag.NewFlagSet("attribute", flag.ContinueOnError)
 
 	var entities bool
 
 	listFs.BoolVar(&entities, "entities", false, "show entity attributes")
 	listFs.BoolVar(&entities, "e", false, "show entity attributes(shorthand)")
 
-	listFs.Parse(s.args)
+	if err := listFs.Parse(s.args); err != nil {
+		return err
+	}
 
 	attributes, err := s.queries.ListAttributes(context.Background(), entities)
 	if err != nil {
 		return err
 	}
 
+	if s.output != outputText {
+		records := make([]attributeRecord, len(attributes))
+		for idx, attribute := range attributes {
+			records[idx], err = toAttributeRecord(attribute, s.queries)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writeRecords(s, records)
+	}
+
 	var sb strings.Builder
 	for _, attribute := range attributes {
 		attributeString, err := attributeToString(attribute, !entities, s.queries)
@@ -135,14 +152,16 @@ func listAttributesCommand(s state) error {
 }
 
 func infoAttributeCommand(s state) error {
-	infoFs := flag.NewFlagSet("attribute", flag.ExitOnError)
+	infoFs := flag.NewFlagSet("attribute", flag.ContinueOnError)
 
 	var hideEntities bool
 
 	infoFs.BoolVar(&hideEntities, "hide-entities", false, "Don't show entities that use this attribute (shorthand)")
 	infoFs.BoolVar(&hideEntities, "E", false, "Don't show entities that use this attribute")
 
-	infoFs.Parse(s.args)
+	if err := infoFs.Parse(s.args); err != nil {
+		return err
+	}
 
 	if infoFs.NArg() < 1 {
 		return fmt.Errorf("%s requires an argument, the slug or id of the attribute to look up", s.cmdName)
@@ -153,6 +172,10 @@ func infoAttributeCommand(s state) error {
 		return err
 	}
 
+	if s.output != outputText {
+		return writeAttribute(s, attribute)
+	}
+
 	var sb strings.Builder
 	attributeString, err := attributeToString(attribute, hideEntities, s.queries)
 	if err != nil {
@@ -170,7 +193,7 @@ func infoAttributeCommand(s state) error {
 }
 
 func updateAttributeCommand(s state) error {
-	updateFs := flag.NewFlagSet("attribute", flag.ExitOnError)
+	updateFs := flag.NewFlagSet("attribute", flag.ContinueOnError)
 
 	var name string
 	var slug string
@@ -185,16 +208,17 @@ func updateAttributeCommand(s state) error {
 	updateFs.StringVar(&newType, "type", "", "New type for an attribute")
 	updateFs.StringVar(&newType, "t", "", "New type for an attribute (shorthand)")
 
-	updateFs.Parse(s.args)
+	if err := updateFs.Parse(s.args); err != nil {
+		return err
+	}
 
-	if !geaves.ValidAttributeType(newType) {
-		fmt.Println("The new type is not valid, ignoring this option")
+	if newType != "" && !geaves.ValidAttributeType(newType) {
+		fmt.Fprintln(os.Stderr, "The new type is not valid, ignoring this option")
 		newType = ""
 	}
 
 	if name == "" && slug == "" && newType == "" {
-		fmt.Println("No valid updating flags were given, nothing to do")
-		os.Exit(1)
+		return fmt.Errorf("No valid updating flags were given, nothing to do")
 	}
 
 	if updateFs.NArg() < 1 {
@@ -207,6 +231,10 @@ func updateAttributeCommand(s state) error {
 	}
 
 	if (name == attribute.Name || name == "") && (slug == attribute.Slug || slug == "") && (geaves.AttributeType(newType) == attribute.Type || newType == "") {
+		if s.output != outputText {
+			return writeAttribute(s, attribute)
+		}
+
 		fmt.Println("Attribute already has these fields, nothing to do")
 		return nil
 	}
@@ -234,6 +262,15 @@ func updateAttributeCommand(s state) error {
 		}
 	}
 
+	if s.output != outputText {
+		updated, err := getAttributeByIdOrSlug(strconv.FormatInt(attribute.ID, 10), true, s.queries)
+		if err != nil {
+			return err
+		}
+
+		return writeAttribute(s, updated)
+	}
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Successfully updated %v", attribute.ID))
 
@@ -270,12 +307,23 @@ func deleteAttributeCommand(s state) error {
 		return err
 	}
 
+	// The record is read before deleting, the links go with the attribute
+	record, err := toAttributeRecord(attribute, s.queries)
+	if err != nil {
+		return err
+	}
+
 	err = s.queries.DeleteAttribute(context.Background(), attribute.ID)
-	if err == nil {
-		fmt.Printf("Successfully delete %s\n", attribute.Name)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, record)
 	}
 
-	return err
+	fmt.Printf("Successfully delete %s\n", attribute.Name)
+	return nil
 }
 
 func getAttributeByIdOrSlug(search string, entities bool, queries *geaves.Queries) (geaves.Attribute, error) {
@@ -287,6 +335,16 @@ func getAttributeByIdOrSlug(search string, entities bool, queries *geaves.Querie
 	return queries.GetAttribute(context.Background(), geaves.GetAttributeParam{WithEntities: entities, Field: geaves.BySlug, Value: search})
 }
 
+// writeAttribute writes an attribute with the entities using it in the output format of s
+func writeAttribute(s state, attribute geaves.Attribute) error {
+	record, err := toAttributeRecord(attribute, s.queries)
+	if err != nil {
+		return err
+	}
+
+	return writeRecord(s, record)
+}
+
 func attributeToString(attribute geaves.Attribute, skipEntities bool, queries *geaves.Queries) (string, error) {
 	var sb strings.Builder
 
@@ -334,7 +392,8 @@ Required flags
   -s | --slug  - slug of the new attribute
   -t | --type  - type of the new attribute
 
-Type MUST be one of bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, byte, rune, float32, float64, blob, date, time, datetime
+Type MUST be one of bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, byte, rune, float32, float64, blob, date, time, datetime,
+decimal(p,s) or money(p,s), with p digits of which s after the point (p at most 18), money values are written like 12.30 EUR
 `)
 			return
 		case "update":
@@ -349,7 +408,8 @@ Available flags
   -s | --slug  - slug of the new attribute
   -t | --type  - type of the new attribute
 
-Type MUST be one of bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, byte, rune, float32, float64, blob, date, time, datetime
+Type MUST be one of bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, byte, rune, float32, float64, blob, date, time, datetime,
+decimal(p,s) or money(p,s), with p digits of which s after the point (p at most 18), money values are written like 12.30 EUR
 
 One of name, slug or type is required
 `)
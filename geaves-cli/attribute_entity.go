@@ -19,11 +19,16 @@ func linkAttributeEntityCommand(s state) error {
 	}
 
 	_, err  = s.queries.CreateEntityAttribute(context.Background(), geaves.EntityAttribute{EntityID: entity.ID, AttributeID: attribute.ID})
-	if err == nil {
-		fmt.Printf("Succesfully linked %s to %s\n", attribute.Name, entity.Name)
+	if err != nil {
+		return err
 	}
 
-	return err
+	if s.output != outputText {
+		return writeRecord(s, linkRecord{Entity: entity.Slug, Attribute: attribute.Slug, Linked: true})
+	}
+
+	fmt.Printf("Succesfully linked %s to %s\n", attribute.Name, entity.Name)
+	return nil
 }
 
 func linkRequiredAttributeEntityCommand(s state) error {
@@ -38,11 +43,16 @@ func linkRequiredAttributeEntityCommand(s state) error {
 	}
 
 	_, err  = s.queries.CreateEntityAttribute(context.Background(), geaves.EntityAttribute{EntityID: entity.ID, AttributeID: attribute.ID, Required: true})
-	if err == nil {
-		fmt.Printf("Succesfully linked %s (required) to %s\n", attribute.Name, entity.Name)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, linkRecord{Entity: entity.Slug, Attribute: attribute.Slug, Linked: true, Required: true})
 	}
 
-	return err
+	fmt.Printf("Succesfully linked %s (required) to %s\n", attribute.Name, entity.Name)
+	return nil
 }
 
 func requireEntityAttributeCommand(s state) error {
@@ -57,11 +67,16 @@ func requireEntityAttributeCommand(s state) error {
 	}
 
 	err = s.queries.UpdateRequireEntityAttribute(context.Background(), true, entity.ID, attribute.ID)
-	if err == nil {
-		fmt.Printf("Succesfully make %s required on %s\n", attribute.Name, entity.Name)
+	if err != nil {
+		return err
 	}
 
-	return err
+	if s.output != outputText {
+		return writeRecord(s, linkRecord{Entity: entity.Slug, Attribute: attribute.Slug, Linked: true, Required: true})
+	}
+
+	fmt.Printf("Succesfully make %s required on %s\n", attribute.Name, entity.Name)
+	return nil
 }
 
 func optionalEntityAttributeCommand(s state) error {
@@ -76,11 +91,16 @@ func optionalEntityAttributeCommand(s state) error {
 	}
 
 	err = s.queries.UpdateRequireEntityAttribute(context.Background(), false, entity.ID, attribute.ID)
-	if err == nil {
-		fmt.Printf("Succesfully made %s optional on %s\n", attribute.Name, entity.Name)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, linkRecord{Entity: entity.Slug, Attribute: attribute.Slug, Linked: true})
 	}
 
-	return err
+	fmt.Printf("Succesfully made %s optional on %s\n", attribute.Name, entity.Name)
+	return nil
 
 }
 
@@ -96,11 +116,16 @@ func unlinkAttributeEntityCommand(s state) error {
 	}
 
 	err = s.queries.DeleteEntityAttribute(context.Background(), entity.ID, attribute.ID)
-	if err == nil {
-		fmt.Printf("Succesfully unlinked %s from %s\n", attribute.Name, entity.Name)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, linkRecord{Entity: entity.Slug, Attribute: attribute.Slug})
 	}
 
-	return err
+	fmt.Printf("Succesfully unlinked %s from %s\n", attribute.Name, entity.Name)
+	return nil
 }
 
 func getEntityAndAttribute(queries *geaves.Queries, entitySlug string, attributeSlug string) (geaves.Entity, geaves.Attribute, error) {
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+	"github.com/Asfolny/geaves"
+	"github.com/Asfolny/geaves/client"
+	"github.com/Asfolny/geaves/server"
+)
+
+// The client and server packages live in the geaves module, which has no sqlite driver to test them with,
+// so their round trip is tested here
+
+// newTestClient serves a fresh database with server.NewHandler and returns a Client for it
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	// A file rather than memory, every connection of the pool has to see the same database
+	uri := filepath.Join(t.TempDir(), "geaves.db") + "?_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", uri)
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(geaves.SetupSQL()); err != nil {
+		t.Fatalf("Failed to set up database: %s", err)
+	}
+
+	srv := httptest.NewServer(server.NewHandler(db))
+	t.Cleanup(srv.Close)
+
+	return client.New(srv.URL, client.WithHTTPClient(srv.Client()))
+}
+
+func TestEntityCRUD(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	entity, err := c.CreateEntity(ctx, geaves.CreateEntityParam{Name: "Product", Slug: "product"})
+	if err != nil {
+		t.Fatalf("CreateEntity: %s", err)
+	}
+
+	if entity.ID == 0 || entity.Name != "Product" || entity.Slug != "product" {
+		t.Fatalf("CreateEntity returned %+v", entity)
+	}
+
+	if err := c.UpdateEntityName(ctx, "Goods", entity.ID); err != nil {
+		t.Fatalf("UpdateEntityName: %s", err)
+	}
+
+	if err := c.UpdateEntitySlug(ctx, "goods", entity.ID); err != nil {
+		t.Fatalf("UpdateEntitySlug: %s", err)
+	}
+
+	got, err := c.GetEntity(ctx, geaves.GetEntityParam{Field: geaves.BySlug, Value: "goods"})
+	if err != nil {
+		t.Fatalf("GetEntity by slug: %s", err)
+	}
+
+	if got.ID != entity.ID || got.Name != "Goods" {
+		t.Fatalf("GetEntity returned %+v, expected Goods with id %v", got, entity.ID)
+	}
+
+	entities, err := c.ListEntities(ctx, false)
+	if err != nil {
+		t.Fatalf("ListEntities: %s", err)
+	}
+
+	if len(entities) != 1 || entities[0].ID != entity.ID {
+		t.Fatalf("ListEntities returned %+v", entities)
+	}
+
+	if err := c.DeleteEntity(ctx, entity.ID); err != nil {
+		t.Fatalf("DeleteEntity: %s", err)
+	}
+
+	_, err = c.GetEntity(ctx, geaves.GetEntityParam{Field: geaves.ByID, Value: entity.ID})
+	if !errors.Is(err, client.ErrNotFound) || !errors.Is(err, geaves.ErrNotFound) {
+		t.Fatalf("GetEntity of a deleted entity returned %v, expected not found", err)
+	}
+}
+
+func TestAttributeCRUD(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	attribute, err := c.CreateAttribute(ctx, geaves.CreateAttributeParam{Name: "Price", Slug: "price", Type: geaves.Float64Type})
+	if err != nil {
+		t.Fatalf("CreateAttribute: %s", err)
+	}
+
+	if attribute.ID == 0 || attribute.Type != geaves.Float64Type {
+		t.Fatalf("CreateAttribute returned %+v", attribute)
+	}
+
+	if err := c.UpdateAttributeName(ctx, "Cost", attribute.ID); err != nil {
+		t.Fatalf("UpdateAttributeName: %s", err)
+	}
+
+	if err := c.UpdateAttributeSlug(ctx, "cost", attribute.ID); err != nil {
+		t.Fatalf("UpdateAttributeSlug: %s", err)
+	}
+
+	if err := c.UpdateAttributeType(ctx, geaves.Int64Type, attribute.ID); err != nil {
+		t.Fatalf("UpdateAttributeType: %s", err)
+	}
+
+	got, err := c.GetAttribute(ctx, geaves.GetAttributeParam{Field: geaves.BySlug, Value: "cost"})
+	if err != nil {
+		t.Fatalf("GetAttribute by slug: %s", err)
+	}
+
+	expected := geaves.Attribute{ID: attribute.ID, Name: "Cost", Slug: "cost", Type: geaves.Int64Type}
+	if got.ID != expected.ID || got.Name != expected.Name || got.Slug != expected.Slug || got.Type != expected.Type {
+		t.Fatalf("GetAttribute returned %+v, expected %+v", got, expected)
+	}
+
+	attributes, err := c.ListAttributes(ctx, false)
+	if err != nil {
+		t.Fatalf("ListAttributes: %s", err)
+	}
+
+	if len(attributes) != 1 || attributes[0].ID != attribute.ID {
+		t.Fatalf("ListAttributes returned %+v", attributes)
+	}
+
+	if err := c.DeleteAttribute(ctx, attribute.ID); err != nil {
+		t.Fatalf("DeleteAttribute: %s", err)
+	}
+
+	_, err = c.GetAttribute(ctx, geaves.GetAttributeParam{Field: geaves.ByID, Value: attribute.ID})
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetAttribute of a deleted attribute returned %v, expected not found", err)
+	}
+}
+
+func TestItemValues(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	entity, err := c.CreateEntity(ctx, geaves.CreateEntityParam{Name: "Product", Slug: "product"})
+	if err != nil {
+		t.Fatalf("CreateEntity: %s", err)
+	}
+
+	name, err := c.CreateAttribute(ctx, geaves.CreateAttributeParam{Name: "Name", Slug: "name", Type: geaves.StringType})
+	if err != nil {
+		t.Fatalf("CreateAttribute: %s", err)
+	}
+
+	stock, err := c.CreateAttribute(ctx, geaves.CreateAttributeParam{Name: "Stock", Slug: "stock", Type: geaves.Int64Type})
+	if err != nil {
+		t.Fatalf("CreateAttribute: %s", err)
+	}
+
+	if _, err := c.CreateEntityAttribute(ctx, geaves.EntityAttribute{EntityID: entity.ID, AttributeID: name.ID, Required: true}); err != nil {
+		t.Fatalf("CreateEntityAttribute: %s", err)
+	}
+
+	if _, err := c.CreateEntityAttribute(ctx, geaves.EntityAttribute{EntityID: entity.ID, AttributeID: stock.ID}); err != nil {
+		t.Fatalf("CreateEntityAttribute: %s", err)
+	}
+
+	linked, err := c.LoadAttributesByEntity(ctx, entity.ID)
+	if err != nil {
+		t.Fatalf("LoadAttributesByEntity: %s", err)
+	}
+
+	if len(linked) != 2 {
+		t.Fatalf("LoadAttributesByEntity returned %+v, expected name and stock", linked)
+	}
+
+	_, err = c.CreateItem(ctx, entity.ID)
+	if !errors.Is(err, client.ErrBadRequest) || !errors.Is(err, geaves.ErrRequiredMissing) {
+		t.Fatalf("CreateItem without the required name returned %v, expected a missing required attribute", err)
+	}
+
+	item, err := c.CreateItemWithValues(ctx, entity.ID, map[string]geaves.Value{
+		"name": {Type: geaves.StringType, Data: "Chair"},
+	})
+	if err != nil {
+		t.Fatalf("CreateItemWithValues: %s", err)
+	}
+
+	if err := c.SetItemValue(ctx, item.ID, stock.ID, geaves.Value{Type: geaves.Int64Type, Data: int64(12)}); err != nil {
+		t.Fatalf("SetItemValue: %s", err)
+	}
+
+	value, err := c.GetItemValue(ctx, item.ID, stock.ID)
+	if err != nil {
+		t.Fatalf("GetItemValue: %s", err)
+	}
+
+	if value.Slug != "stock" || value.Value.Data != int64(12) {
+		t.Fatalf("GetItemValue returned %+v, expected stock of 12", value)
+	}
+
+	values, err := c.ListItemValues(ctx, item.ID)
+	if err != nil {
+		t.Fatalf("ListItemValues: %s", err)
+	}
+
+	bySlug := map[string]any{}
+	for _, v := range values {
+		bySlug[v.Slug] = v.Value.Data
+	}
+
+	if bySlug["name"] != "Chair" || bySlug["stock"] != int64(12) {
+		t.Fatalf("ListItemValues returned %+v", values)
+	}
+
+	items, err := c.ListEntityItems(ctx, entity.ID)
+	if err != nil {
+		t.Fatalf("ListEntityItems: %s", err)
+	}
+
+	if len(items) != 1 || items[0].ID != item.ID {
+		t.Fatalf("ListEntityItems returned %+v", items)
+	}
+
+	if err := c.DeleteItemAttributes(ctx, item.ID, stock.ID); err != nil {
+		t.Fatalf("DeleteItemAttributes: %s", err)
+	}
+
+	_, err = c.GetItemValue(ctx, item.ID, stock.ID)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetItemValue of a deleted value returned %v, expected not found", err)
+	}
+
+	if err := c.DeleteItem(ctx, item.ID); err != nil {
+		t.Fatalf("DeleteItem: %s", err)
+	}
+
+	_, err = c.GetItem(ctx, item.ID)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetItem of a deleted item returned %v, expected not found", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	if _, err := c.CreateEntity(ctx, geaves.CreateEntityParam{Name: "Product", Slug: "product"}); err != nil {
+		t.Fatalf("CreateEntity: %s", err)
+	}
+
+	_, err := c.CreateEntity(ctx, geaves.CreateEntityParam{Name: "Other", Slug: "product"})
+	if !errors.Is(err, client.ErrConflict) || !errors.Is(err, geaves.ErrDuplicateSlug) || !errors.Is(err, geaves.ErrDuplicate) {
+		t.Fatalf("CreateEntity with a taken slug returned %v, expected a duplicate slug", err)
+	}
+
+	if errors.Is(err, geaves.ErrDuplicateName) {
+		t.Fatalf("CreateEntity with a taken slug returned %v, which should not be a duplicate name", err)
+	}
+
+	_, err = c.CreateAttribute(ctx, geaves.CreateAttributeParam{Name: "Price", Slug: "price", Type: "float128"})
+	if !errors.Is(err, client.ErrBadRequest) || !errors.Is(err, geaves.ErrInvalidType) {
+		t.Fatalf("CreateAttribute with an unknown type returned %v, expected an invalid type", err)
+	}
+
+	_, err = c.GetItem(ctx, 404)
+	if !errors.Is(err, client.ErrNotFound) || !errors.Is(err, geaves.ErrNotFound) {
+		t.Fatalf("GetItem of a missing item returned %v, expected not found", err)
+	}
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 || apiErr.Code != "not_found" {
+		t.Fatalf("GetItem of a missing item returned %#v, expected a 404 client.Error", err)
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/Asfolny/geaves"
 )
@@ -10,20 +14,70 @@ type state struct {
 	cmdName string
 	args []string
 	queries *geaves.Queries
+	// db is the connection queries runs a transaction on, for commands that manage their own transactions
+	db *sql.DB
+	// output is the format results are written in, see output.go
+	output outputFormat
+	// tenant is the id of the tenant queries are scoped to, for commands that manage their own transactions
+	tenant int64
 }
 
 type command struct {
 	name string
 	description string
 	callback func(state) error
+	// ownTx commands are not run in a transaction, they begin their own on db
+	ownTx bool
+	// readOnly commands run in a deferred transaction, which takes no lock until it reads,
+	// so their output can be piped into another connection to the same database
+	readOnly bool
+}
+
+// errRollback is returned by commands that succeeded but whose changes must not be committed
+var errRollback = errors.New("rollback requested")
+
+// parseInterspersed parses flags that may come before, between or after the arguments,
+// returning the arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return positional, err
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// stringsFlag collects a flag given multiple times, or once with comma separated values
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
 }
 
 func getTopCommands() map[string]command {
 	return map[string]command{
 		"generate": {
 			name: "generate [type]",
-			description: "Generate setup script\ntype can be one of 'setup-sql' (default), 'reset-sql' or 'goose'",
+			description: "Generate setup script\ntype can be one of 'setup-sql' (default), 'reset-sql', 'goose' or 'views'",
 			callback: generateCommand,
+			readOnly: true,
 		},
 		"entity": {
 			name: "entity <sub command>",
@@ -65,9 +119,47 @@ func getTopCommands() map[string]command {
 			description: "Manage items using sub commands, see item help",
 			callback: itemCommand,
 		},
+		"tenant": {
+			name: "tenant <sub command>",
+			description: "Manage tenants using sub commands, see tenant help",
+			callback: tenantCommand,
+		},
+		"serve": {
+			name: "serve <flags>",
+			description: "Serve the database as a JSON API over HTTP",
+			callback: serveCommand,
+			ownTx: true,
+		},
+		"shell": {
+			name: "shell",
+			description: "Run commands interactively over one connection",
+			callback: shellCommand,
+			ownTx: true,
+		},
+		"schema": {
+			name: "schema <sub command>",
+			description: "Manage the schema from schema files using sub commands, see schema help",
+			callback: schemaCommand,
+		},
+		"export": {
+			name: "export [file]",
+			description: "Export the whole database as json, to stdout or file",
+			callback: exportCommand,
+			readOnly: true,
+		},
+		"import": {
+			name: "import <flags> [file]",
+			description: "Import a json export, from stdin or file",
+			callback: importCommand,
+		},
+		"migrate": {
+			name: "migrate <flags>",
+			description: "Rewrite stored values into the layout they are written in now",
+			callback: migrateCommand,
+		},
 		"help": {
 			name: "help",
-			description: "Prints this message, make note that some command structures are nested and may have thier own sub help commands\nitem help\n attribute help\nentity help\n",
+			description: "Prints this message, make note that some command structures are nested and may have thier own sub help commands\nitem help\n attribute help\nentity help\nschema help\ntenant help\n",
 			callback: helpCommand,
 		},
 	}
@@ -83,9 +175,27 @@ geaves-cli generate [type]
 Generate migrations a user may need, and print it to stdout
 
 Available types
-  setup-sql  - Generate only the content of +goose Up
-  reset-sql  - Generate only the content of +goose Down
-  goose      - Generate goose format
+  setup-sql   - Generate only the content of +goose Up
+  reset-sql   - Generate only the content of +goose Down
+  tenants-sql - Upgrade a database set up before tenants, keeping everything in the default tenant,
+                pipe it into sqlite3 as it turns foreign keys off while it runs
+  goose       - Generate goose format
+  views       - Generate one view per entity, with a column per linked attribute
+  go          - Generate a Go struct per entity, with Load, List and Save functions
+  openapi     - Generate an OpenAPI 3 document of the item endpoints of serve, with a schema per entity
+  jsonschema  - Generate a JSON Schema (draft 2020-12) of the values of an entity, given as
+                jsonschema [entity], or of every entity under $defs when none is given
+
+Available flags for views
+  -c | --create  - Create the views in the database instead of printing them,
+                   once created the views are recreated whenever entities, attributes or links change
+
+Available flags for go
+  -p | --package [name]  - Package name of the generated code, defaults to models
+  -o | --output [file]   - Write the generated code to file instead of stdout
+
+Available flags for openapi
+  -o | --output [file]  - Write the document to file instead of stdout
 `)
 			return
 		case "entity":
@@ -142,6 +252,87 @@ Make an attribute required on a specific entity; must provide entity slug and at
 geaves-cli optional <entity> <attribute>
 
 Make an attribute optional on a specific entity; must provide entity slug and attribute slug
+`)
+			return
+		case "tenant":
+			fmt.Print(`
+geaves-cli tenant <subcommand>
+
+Manage tenants in the system, see tenant help instead
+`)
+			return
+		case "schema":
+			fmt.Print(`
+geaves-cli schema <subcommand>
+
+Plan and apply schema files, see schema help instead
+`)
+			return
+		case "export":
+			fmt.Print(`
+geaves-cli export [file]
+
+Export all entities, attributes, links, items and values as versioned json, to file or stdout when no file (or -) is given
+Blobs are base64 encoded and date, time and datetime values are written as RFC 3339
+`)
+			return
+		case "import":
+			fmt.Print(`
+geaves-cli import <flags> [file]
+NOTE flags must be before arguments
+
+Import json made by export, from file or stdin when no file (or -) is given
+Entities and attributes are matched to existing ones by slug, items are always created with new ids
+Everything is imported in one transaction, if anything fails nothing is imported
+
+Available flags
+  -d | --dry-run  - Import and report what was imported, then roll everything back
+`)
+			return
+		case "migrate":
+			fmt.Print(`
+geaves-cli migrate <flags>
+
+Rewrite every stored value that is not in the layout it is written in now, in one transaction
+Values already stored that way are left alone, so migrate can be run any number of times
+
+Date, time and datetime values are stored as text that sorts in time order, datetimes in UTC
+  date      - 2006-01-02
+  time      - 15:04:05.000000000
+  datetime  - 2006-01-02T15:04:05.000000000Z
+Values stored before then are read until migrated, but do not compare correctly in filters
+
+Available flags
+  -d | --dry-run  - Rewrite and report how many values were rewritten, then roll everything back
+`)
+			return
+		case "serve":
+			fmt.Print(`
+geaves-cli serve <flags>
+
+Serve entities, attributes, links and items as a JSON API over HTTP until interrupted
+Every request runs in its own transaction, see the server package for the endpoints
+Only the tenant given with --tenant before serve is served
+
+Available flags
+  -a | --addr [address]  - Address to listen on, defaults to :8080
+`)
+			return
+		case "shell":
+			fmt.Print(`
+geaves-cli shell
+
+Read commands line by line and run them over one connection, each in its own transaction
+Commands run in the tenant given with --tenant before shell, serve and shell can not run in it
+Tab completes commands, sub commands and the slugs of entities and attributes
+History is kept in ~/.geaves_history, up and down walk through it
+
+Shell commands
+  begin     - Start a transaction, following commands run in it until commit or rollback
+  commit    - Commit the transaction
+  rollback  - Roll the transaction back
+  history   - Print the command history
+  exit      - Leave the shell, an open transaction is rolled back
 `)
 			return
 		default:
@@ -150,7 +341,26 @@ Make an attribute optional on a specific entity; must provide entity slug and at
 	}
 
 	fmt.Print(`
-geaves-cli <command>
+geaves-cli [--output format] [--tenant slug|id] <command>
+
+Available flags, before the command
+  --output [format]             - One of text (default), json, jsonl, csv or table
+                                  Entity, attribute, item, link, schema, import and migrate commands write
+                                  their results as records,
+                                  json and jsonl write errors as {"error": "..."} to stderr
+  --tenant [slug|id]            - Run the command in a tenant, commands only see the entities, attributes
+                                  and items of their tenant, the default tenant when none is given
+
+Exit codes
+  1 - Any other failure
+  2 - Invalid flags
+  3 - A tenant, entity, attribute, item or value was not found
+  4 - A slug or name already exists
+  5 - An invalid type or value
+  6 - A required attribute is missing
+  7 - A referenced row does not exist or is still referred to
+  8 - The database stayed busy or locked
+  9 - Money in different currencies was added up
 
 Available commands
   generate [type]               - Generate migrations which the user may need
@@ -162,6 +372,13 @@ Available commands
   linkreq <entity> <attribute>  - Link an entity to a required attribute by entity slug and attribute slug
   require <entity> <attribute>  - Make an attribute required on an entity by entity slug and attribute slug
   optional <entity> <attribute> - Make an attribute optional on an entity by entity slug and attribute slug
+  schema <subcommand>           - Schema file handling, see schema help for more details
+  tenant <subcommand>           - Tenant handling, see tenant help for more details
+  export [file]                 - Export the whole database as json
+  import <flags> [file]         - Import json made by export
+  migrate <flags>               - Rewrite stored values into the current layout, see migrate help
+  serve <flags>                 - Serve the database as a JSON API over HTTP
+  shell                         - Run commands interactively, see shell help for more details
   help [command]                - Prints this message, or the help details of a command
 `)
 	return
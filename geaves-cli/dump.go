@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Asfolny/geaves"
+)
+
+func exportCommand(s state) error {
+	dump, err := geaves.ExportDump(context.Background(), s.queries)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if len(s.args) >= 1 && s.args[0] != "-" {
+		f, err := os.Create(s.args[0])
+		if err != nil {
+			return fmt.Errorf("Failed to create export file: %w", err)
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	return geaves.WriteDump(w, dump)
+}
+
+func importCommand(s state) error {
+	importFs := flag.NewFlagSet("import", flag.ContinueOnError)
+
+	var dryRun bool
+
+	importFs.BoolVar(&dryRun, "dry-run", false, "Import and report, but roll everything back")
+	importFs.BoolVar(&dryRun, "d", false, "Import and report, but roll everything back (shorthand)")
+
+	if err := importFs.Parse(s.args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if importFs.NArg() >= 1 && importFs.Arg(0) != "-" {
+		f, err := os.Open(importFs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("Failed to open import file: %w", err)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	dump, err := geaves.ReadDump(r)
+	if err != nil {
+		return err
+	}
+
+	res, err := geaves.ImportDump(context.Background(), s.queries, dump)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		err := writeRecord(s, importRecord{
+			DryRun: dryRun,
+			EntitiesCreated: res.EntitiesCreated,
+			AttributesCreated: res.AttributesCreated,
+			LinksCreated: res.LinksCreated,
+			LinksUpdated: res.LinksUpdated,
+			ItemsCreated: res.ItemsCreated,
+			ValuesSet: res.ValuesSet,
+		})
+		if err != nil || !dryRun {
+			return err
+		}
+
+		return errRollback
+	}
+
+	prefix := "Successfully imported"
+	if dryRun {
+		prefix = "Dry run, would have imported"
+	}
+
+	fmt.Printf("%s %v items with %v values, created %v entities, %v attributes and %v links, updated %v links\n",
+		prefix,
+		res.ItemsCreated,
+		res.ValuesSet,
+		res.EntitiesCreated,
+		res.AttributesCreated,
+		res.LinksCreated,
+		res.LinksUpdated,
+	)
+
+	if dryRun {
+		return errRollback
+	}
+
+	return nil
+}
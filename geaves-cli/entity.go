@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"os"
 
 	"github.com/Asfolny/geaves"
 )
@@ -23,7 +22,7 @@ func entityCommand(s state) error {
 		return fmt.Errorf("%s: entity command not found\n", s.args[0])
 	}
 
-	return  cmd.callback(state{s.args[0], s.args[1:], s.queries})
+	return  cmd.callback(state{s.args[0], s.args[1:], s.queries, s.db, s.output, s.tenant})
 }
 
 func getEntityCommands() map[string]command {
@@ -62,7 +61,7 @@ func getEntityCommands() map[string]command {
 }
 
 func createEntityCommand(s state) error {
-	registerFs := flag.NewFlagSet("entity", flag.ExitOnError)
+	registerFs := flag.NewFlagSet("entity", flag.ContinueOnError)
 
 	var name string
 	var slug string
@@ -73,11 +72,12 @@ func createEntityCommand(s state) error {
 	registerFs.StringVar(&slug, "slug", "", "Slug for new entity")
 	registerFs.StringVar(&slug, "s", "", "Slug for new entity (shorthand)")
 
-	registerFs.Parse(s.args)
+	if err := registerFs.Parse(s.args); err != nil {
+		return err
+	}
 
 	if name == "" || slug == "" {
-		fmt.Println("Both name and slug must be provided, but one was empty")
-		os.Exit(1)
+		return fmt.Errorf("Both name and slug must be provided, but one was empty")
 	}
 
 	entity, err := s.queries.CreateEntity(context.Background(), geaves.CreateEntityParam{Name: name, Slug: slug})
@@ -85,25 +85,43 @@ func createEntityCommand(s state) error {
 		return err
 	}
 
+	if s.output != outputText {
+		return writeEntity(s, entity)
+	}
+
 	fmt.Printf("Successfully created %s (%s)\n", entity.Name, entity.Slug)
 	return nil
 }
 
 func listEntitiesCommand(s state) error {
-	listFs := flag.NewFlagSet("entity", flag.ExitOnError)
+	listFs := flag.NewFlagSet("entity", flag.ContinueOnError)
 
 	var attributes bool
 
 	listFs.BoolVar(&attributes, "attributes", false, "Hide entity attributes (shorthand)")
 	listFs.BoolVar(&attributes, "a", false, "Hide entity attributes")
 
-	listFs.Parse(s.args)
+	if err := listFs.Parse(s.args); err != nil {
+		return err
+	}
 
 	entities, err := s.queries.ListEntities(context.Background(), attributes)
 	if err != nil {
 		return err
 	}
 
+	if s.output != outputText {
+		records := make([]entityRecord, len(entities))
+		for idx, entity := range entities {
+			records[idx], err = toEntityRecord(entity, s.queries)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writeRecords(s, records)
+	}
+
 	var sb strings.Builder
 	for _, entity := range entities {
 		entityString, err := entityToString(entity, !attributes, s.queries)
@@ -123,14 +141,16 @@ func listEntitiesCommand(s state) error {
 }
 
 func infoEntityCommand(s state) error {
-	infoFs := flag.NewFlagSet("entity", flag.ExitOnError)
+	infoFs := flag.NewFlagSet("entity", flag.ContinueOnError)
 
 	var hideAttributes bool
 
 	infoFs.BoolVar(&hideAttributes, "hide-attributes", false, "Hide entity attributes (shorthand)")
 	infoFs.BoolVar(&hideAttributes, "A", false, "Hide entity attributes")
 
-	infoFs.Parse(s.args)
+	if err := infoFs.Parse(s.args); err != nil {
+		return err
+	}
 
 	if infoFs.NArg() < 1 {
 		return fmt.Errorf("%s requires an argument, the slug or id of the entity to look up", s.cmdName)
@@ -141,6 +161,10 @@ func infoEntityCommand(s state) error {
 		return err
 	}
 
+	if s.output != outputText {
+		return writeEntity(s, entity)
+	}
+
 	entityString, err := entityToString(entity, hideAttributes, s.queries)
 	if err != nil {
 		return err
@@ -158,7 +182,7 @@ func infoEntityCommand(s state) error {
 }
 
 func updateEntityCommand(s state) error {
-	updateFs := flag.NewFlagSet("entity", flag.ExitOnError)
+	updateFs := flag.NewFlagSet("entity", flag.ContinueOnError)
 
 	var name string
 	var slug string
@@ -169,11 +193,12 @@ func updateEntityCommand(s state) error {
 	updateFs.StringVar(&slug, "slug", "", "Update the slug on an entity")
 	updateFs.StringVar(&slug, "s", "", "Update the slug on an entity (shorthand)")
 
-	updateFs.Parse(s.args)
+	if err := updateFs.Parse(s.args); err != nil {
+		return err
+	}
 
 	if name == "" && slug == "" {
-		fmt.Println("No updating flags were given, nothing to do")
-		os.Exit(1)
+		return fmt.Errorf("No updating flags were given, nothing to do")
 	}
 
 	if updateFs.NArg() < 1 {
@@ -186,6 +211,10 @@ func updateEntityCommand(s state) error {
 	}
 
 	if (name == entity.Name || name == "") && (slug == entity.Slug || slug == "") {
+		if s.output != outputText {
+			return writeEntity(s, entity)
+		}
+
 		fmt.Println("Entity already has these fields, nothing to do")
 		return nil
 	}
@@ -206,6 +235,15 @@ func updateEntityCommand(s state) error {
 		}
 	}
 
+	if s.output != outputText {
+		updated, err := getEntityByIdOrSlug(strconv.FormatInt(entity.ID, 10), true, s.queries)
+		if err != nil {
+			return err
+		}
+
+		return writeEntity(s, updated)
+	}
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Successfully updated %v", entity.ID))
 
@@ -235,12 +273,23 @@ func deleteEntityCommand(s state) error {
 		return err
 	}
 
+	// The record is read before deleting, the links go with the entity
+	record, err := toEntityRecord(entity, s.queries)
+	if err != nil {
+		return err
+	}
+
 	err = s.queries.DeleteEntity(context.Background(), entity.ID)
-	if err == nil {
-		fmt.Printf("Successfully delete %s\n", entity.Name)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, record)
 	}
 
-	return err
+	fmt.Printf("Successfully delete %s\n", entity.Name)
+	return nil
 }
 
 func getEntityByIdOrSlug(search string, attributes bool, queries *geaves.Queries) (geaves.Entity, error) {
@@ -252,6 +301,16 @@ func getEntityByIdOrSlug(search string, attributes bool, queries *geaves.Queries
 	return queries.GetEntity(context.Background(), geaves.GetEntityParam{WithAttributes: !attributes, Field: geaves.BySlug, Value: search})
 }
 
+// writeEntity writes an entity with its attributes in the output format of s
+func writeEntity(s state, entity geaves.Entity) error {
+	record, err := toEntityRecord(entity, s.queries)
+	if err != nil {
+		return err
+	}
+
+	return writeRecord(s, record)
+}
+
 func entityToString(entity geaves.Entity, skipAttributes bool, queries *geaves.Queries) (string, error) {
 	var sb strings.Builder
 
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/Asfolny/geaves"
+	"github.com/Asfolny/geaves/server"
 )
 
 func generateCommand(s state) error {
@@ -21,12 +25,101 @@ func generateCommand(s state) error {
 	case "reset-sql":
 		printResetSQL()
 		return nil
+	case "tenants-sql":
+		printTenantsSQL()
+		return nil
 	case "goose":
 		printGoose()
 		return nil
+	case "views":
+		return generateViews(s)
+	case "go":
+		return generateGo(s)
+	case "openapi":
+		return generateOpenAPI(s)
+	case "jsonschema":
+		return generateJSONSchema(s)
 	default:
-		return fmt.Errorf("Invalid setup type '%s', please use one of 'setup-sql' (default), 'reset-sql' or 'goose'", printer)
+		return fmt.Errorf("Invalid setup type '%s', please use one of 'setup-sql' (default), 'reset-sql', 'tenants-sql', 'goose', 'views', 'go', 'openapi' or 'jsonschema'", printer)
+	}
+}
+
+func generateViews(s state) error {
+	viewsFs := flag.NewFlagSet("generate", flag.ContinueOnError)
+
+	var create bool
+
+	viewsFs.BoolVar(&create, "create", false, "Create the views in the database instead of printing them")
+	viewsFs.BoolVar(&create, "c", false, "Create the views in the database instead of printing them (shorthand)")
+
+	if err := viewsFs.Parse(s.args[1:]); err != nil {
+		return err
+	}
+
+	if create {
+		err := geaves.CreateEntityViews(context.Background(), s.queries)
+		if err == nil {
+			fmt.Println("Successfully created entity views")
+		}
+
+		return err
+	}
+
+	views, err := geaves.GenerateEntityViews(context.Background(), s.queries)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(views)
+	return nil
+}
+
+func generateOpenAPI(s state) error {
+	openAPIFs := flag.NewFlagSet("generate", flag.ContinueOnError)
+
+	var output string
+
+	openAPIFs.StringVar(&output, "output", "", "File to write the document to")
+	openAPIFs.StringVar(&output, "o", "", "File to write the document to (shorthand)")
+
+	if err := openAPIFs.Parse(s.args[1:]); err != nil {
+		return err
+	}
+
+	doc, err := server.GenerateOpenAPI(context.Background(), s.queries)
+	if err != nil {
+		return err
+	}
+
+	if output == "" || output == "-" {
+		fmt.Println(string(doc))
+		return nil
+	}
+
+	return os.WriteFile(output, append(doc, '\n'), 0644)
+}
+
+func generateJSONSchema(s state) error {
+	if len(s.args) < 2 {
+		schema, err := geaves.GenerateJSONSchemas(context.Background(), s.queries)
+		if err != nil {
+			return err
+		}
+
+		return geaves.WriteJSONSchema(os.Stdout, schema)
 	}
+
+	entity, err := getEntityByIdOrSlug(s.args[1], false, s.queries)
+	if err != nil {
+		return fmt.Errorf("Failed to get entity: %w", err)
+	}
+
+	schema, err := geaves.GenerateJSONSchema(context.Background(), s.queries, entity)
+	if err != nil {
+		return err
+	}
+
+	return geaves.WriteJSONSchema(os.Stdout, schema)
 }
 
 func printSetupSQL() {
@@ -37,6 +130,10 @@ func printResetSQL() {
 	fmt.Print(geaves.ResetSQL())
 }
 
+func printTenantsSQL() {
+	fmt.Print(geaves.TenantsSQL())
+}
+
 func printGoose() {
 	var sb strings.Builder
 
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Asfolny/geaves"
+)
+
+// goType is how values of an attribute type are held in generated code
+type goType struct {
+	name string
+	constant string
+}
+
+var goTypes = map[geaves.AttributeType]goType{
+	geaves.BoolType: {"bool", "BoolType"},
+	geaves.StringType: {"string", "StringType"},
+	geaves.IntType: {"int", "IntType"},
+	geaves.Int8Type: {"int8", "Int8Type"},
+	geaves.Int16Type: {"int16", "Int16Type"},
+	geaves.Int32Type: {"int32", "Int32Type"},
+	geaves.Int64Type: {"int64", "Int64Type"},
+	geaves.UintType: {"uint", "UintType"},
+	geaves.Uint8Type: {"uint8", "Uint8Type"},
+	geaves.Uint16Type: {"uint16", "Uint16Type"},
+	geaves.Uint32Type: {"uint32", "Uint32Type"},
+	geaves.Uint64Type: {"uint64", "Uint64Type"},
+	geaves.ByteType: {"byte", "ByteType"},
+	geaves.RuneType: {"rune", "RuneType"},
+	geaves.Float32Type: {"float32", "Float32Type"},
+	geaves.Float64Type: {"float64", "Float64Type"},
+	geaves.BlobType: {"[]byte", "BlobType"},
+	geaves.DateType: {"time.Time", "DateType"},
+	geaves.TimeType: {"time.Time", "TimeType"},
+	geaves.DatetimeType: {"time.Time", "DatetimeType"},
+	geaves.DecimalType: {"geaves.Decimal", "NewDecimalType"},
+	geaves.MoneyType: {"geaves.Money", "NewMoneyType"},
+}
+
+type goField struct {
+	name string
+	param string
+	slug string
+	required bool
+	typ goType
+}
+
+func generateGo(s state) error {
+	goFs := flag.NewFlagSet("generate", flag.ContinueOnError)
+
+	var pkg string
+	var output string
+
+	goFs.StringVar(&pkg, "package", "models", "Package name of the generated code")
+	goFs.StringVar(&pkg, "p", "models", "Package name of the generated code (shorthand)")
+
+	goFs.StringVar(&output, "output", "", "File to write the generated code to")
+	goFs.StringVar(&output, "o", "", "File to write the generated code to (shorthand)")
+
+	if err := goFs.Parse(s.args[1:]); err != nil {
+		return err
+	}
+
+	entities, err := s.queries.ListEntities(context.Background(), true)
+	if err != nil {
+		return fmt.Errorf("Failed to list entities: %w", err)
+	}
+
+	var body strings.Builder
+	var usesTime bool
+	usedNames := map[string]bool{}
+
+	for _, entity := range entities {
+		attributes, err := entity.GetAttributes(context.Background(), s.queries)
+		if err != nil {
+			return fmt.Errorf("Failed to get attributes of %s: %w", entity.Slug, err)
+		}
+
+		name := uniqueEntityIdent(goIdent(entity.Slug), usedNames)
+
+		// Required fields first, so they line up with the constructor parameters
+		attributes = slices.Clone(attributes)
+		slices.SortStableFunc(attributes, func(a, b geaves.EntityAttributeEmbed) int {
+			switch {
+			case a.Required == b.Required:
+				return strings.Compare(a.Slug, b.Slug)
+			case a.Required:
+				return -1
+			default:
+				return 1
+			}
+		})
+
+		fieldNames := map[string]bool{"ID": true}
+		var fields []goField
+		for _, attribute := range attributes {
+			typ, ok := goTypes[attribute.Type.Base()]
+			if !ok {
+				return fmt.Errorf("Attribute %s has type %s which has no Go type", attribute.Slug, attribute.Type)
+			}
+
+			// Parameterized types are constructed with their parameters
+			if precision, scale, ok := attribute.Type.DecimalParams(); ok {
+				typ.constant = fmt.Sprintf("%s(%d, %d)", typ.constant, precision, scale)
+			}
+
+			if strings.HasPrefix(typ.name, "time.") {
+				usesTime = true
+			}
+
+			fieldName := uniqueIdent(goIdent(attribute.Slug), fieldNames)
+			fields = append(fields, goField{
+				name: fieldName,
+				param: goParam(fieldName),
+				slug: attribute.Slug,
+				required: attribute.Required,
+				typ: typ,
+			})
+		}
+
+		writeGoEntity(&body, entity, name, fields)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by geaves-cli generate go; DO NOT EDIT.\n\n")
+	sb.WriteString(fmt.Sprintf("package %s\n\n", pkg))
+	sb.WriteString("import (\n\t\"context\"\n\t\"errors\"\n\t\"fmt\"\n")
+	if usesTime {
+		sb.WriteString("\t\"time\"\n")
+	}
+	sb.WriteString("\n\t\"github.com/Asfolny/geaves\"\n)\n\n")
+	sb.WriteString(goHelpers)
+	sb.WriteString(body.String())
+
+	src, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return fmt.Errorf("Failed to format generated code: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" && output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("Failed to create output file: %w", err)
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+func writeGoEntity(sb *strings.Builder, entity geaves.Entity, name string, fields []goField) {
+	recv := "i"
+
+	sb.WriteString(fmt.Sprintf("// %s is an item of entity %s (%s)\n", name, entity.Name, entity.Slug))
+	sb.WriteString(fmt.Sprintf("type %s struct {\n\tID int64\n", name))
+	for _, field := range fields {
+		if field.required {
+			sb.WriteString(fmt.Sprintf("\t%s %s\n", field.name, field.typ.name))
+		} else {
+			sb.WriteString(fmt.Sprintf("\t%s *%s\n", field.name, field.typ.name))
+		}
+	}
+	sb.WriteString("}\n\n")
+
+	var params []string
+	var assigns []string
+	for _, field := range fields {
+		if field.required {
+			params = append(params, fmt.Sprintf("%s %s", field.param, field.typ.name))
+			assigns = append(assigns, fmt.Sprintf("%s: %s", field.name, field.param))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("// New%s returns a %s which is not saved yet, taking every required attribute\n", name, name))
+	sb.WriteString(fmt.Sprintf("func New%s(%s) *%s {\n", name, strings.Join(params, ", "), name))
+	sb.WriteString(fmt.Sprintf("\treturn &%s{%s}\n}\n\n", name, strings.Join(assigns, ", ")))
+
+	sb.WriteString(fmt.Sprintf("func Load%s(ctx context.Context, q *geaves.Queries, id int64) (*%s, error) {\n", name, name))
+	sb.WriteString(fmt.Sprintf("\tentityID, err := geavesEntityID(ctx, q, %q)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n", entity.Slug))
+	sb.WriteString("\titem, err := q.GetItem(ctx, id)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n")
+	sb.WriteString(fmt.Sprintf("\tif item.EntityID != entityID {\n\t\treturn nil, fmt.Errorf(\"item %%v is not a %s\", id)\n\t}\n\n", entity.Slug))
+	sb.WriteString(fmt.Sprintf("\treturn load%s(ctx, q, id)\n}\n\n", name))
+
+	sb.WriteString(fmt.Sprintf("func load%s(ctx context.Context, q *geaves.Queries, id int64) (*%s, error) {\n", name, name))
+	sb.WriteString("\tvalues, err := q.ListItemValues(ctx, id)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n")
+	sb.WriteString(fmt.Sprintf("\t%s := &%s{ID: id}\n", recv, name))
+	if len(fields) > 0 {
+		sb.WriteString("\tfor _, value := range values {\n\t\tif value.IsNull() {\n\t\t\tcontinue\n\t\t}\n\n\t\tswitch value.Slug {\n")
+		for _, field := range fields {
+			sb.WriteString(fmt.Sprintf("\t\tcase %q:\n", field.slug))
+			sb.WriteString(fmt.Sprintf("\t\t\tv, ok := value.Data.(%s)\n", field.typ.name))
+			sb.WriteString(fmt.Sprintf("\t\t\tif !ok {\n\t\t\t\treturn nil, fmt.Errorf(\"%s on item %%v is %%T, expected %s\", id, value.Data)\n\t\t\t}\n", field.slug, field.typ.name))
+			if field.required {
+				sb.WriteString(fmt.Sprintf("\t\t\t%s.%s = v\n", recv, field.name))
+			} else {
+				sb.WriteString(fmt.Sprintf("\t\t\t%s.%s = &v\n", recv, field.name))
+			}
+		}
+		sb.WriteString("\t\t}\n\t}\n\n")
+	} else {
+		sb.WriteString("\t_ = values\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("\treturn %s, nil\n}\n\n", recv))
+
+	sb.WriteString(fmt.Sprintf("func List%sItems(ctx context.Context, q *geaves.Queries) ([]*%s, error) {\n", name, name))
+	sb.WriteString(fmt.Sprintf("\tentityID, err := geavesEntityID(ctx, q, %q)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n", entity.Slug))
+	sb.WriteString("\titems, err := q.QueryItems(ctx, geaves.ItemQuery{EntityID: entityID})\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n")
+	sb.WriteString(fmt.Sprintf("\tresult := make([]*%s, 0, len(items))\n", name))
+	sb.WriteString(fmt.Sprintf("\tfor _, item := range items {\n\t\t%s, err := load%s(ctx, q, item.ID)\n\t\tif err != nil {\n\t\t\treturn result, err\n\t\t}\n\n\t\tresult = append(result, %s)\n\t}\n\n", recv, name, recv))
+	sb.WriteString("\treturn result, nil\n}\n\n")
+
+	sb.WriteString(fmt.Sprintf("// Save creates the item when it has no ID yet, and stores every attribute, optional attributes left nil have their value removed.\n"))
+	sb.WriteString(fmt.Sprintf("// Everything is saved in one transaction, or a savepoint when q is in one already, so a failing Save leaves nothing behind\n"))
+	sb.WriteString(fmt.Sprintf("func (%s *%s) Save(ctx context.Context, q *geaves.Queries) error {\n", recv, name))
+	sb.WriteString(fmt.Sprintf("\tid := %s.ID\n", recv))
+	sb.WriteString("\terr := q.RunInTx(ctx, func(q *geaves.Queries) error {\n")
+	sb.WriteString(fmt.Sprintf("\t\tid = %s.ID\n", recv))
+	sb.WriteString("\t\tif id == 0 {\n")
+	sb.WriteString(fmt.Sprintf("\t\t\tentityID, err := geavesEntityID(ctx, q, %q)\n\t\t\tif err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\n", entity.Slug))
+	sb.WriteString("\t\t\titem, err := q.CreateItem(ctx, entityID)\n\t\t\tif err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\n")
+	sb.WriteString("\t\t\tid = item.ID\n\t\t}\n\n")
+	for _, field := range fields {
+		if field.required {
+			sb.WriteString(fmt.Sprintf("\t\tif err := geavesSet(ctx, q, id, %q, geaves.%s, %s.%s); err != nil {\n\t\t\treturn err\n\t\t}\n\n", field.slug, field.typ.constant, recv, field.name))
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("\t\tif %s.%s == nil {\n", recv, field.name))
+		sb.WriteString(fmt.Sprintf("\t\t\tif err := geavesUnset(ctx, q, id, %q); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n", field.slug))
+		sb.WriteString(fmt.Sprintf("\t\t} else if err := geavesSet(ctx, q, id, %q, geaves.%s, *%s.%s); err != nil {\n\t\t\treturn err\n\t\t}\n\n", field.slug, field.typ.constant, recv, field.name))
+	}
+	sb.WriteString("\t\treturn nil\n\t})\n")
+	sb.WriteString("\tif err != nil {\n\t\treturn err\n\t}\n\n")
+	sb.WriteString("\t// Only keep the id once the item is committed\n")
+	sb.WriteString(fmt.Sprintf("\t%s.ID = id\n\treturn nil\n}\n\n", recv))
+}
+
+const goHelpers = `func geavesEntityID(ctx context.Context, q *geaves.Queries, slug string) (int64, error) {
+	entity, err := q.GetEntity(ctx, geaves.GetEntityParam{Field: geaves.BySlug, Value: slug})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get entity %s: %w", slug, err)
+	}
+
+	return entity.ID, nil
+}
+
+func geavesSet(ctx context.Context, q *geaves.Queries, itemID int64, slug string, t geaves.AttributeType, data any) error {
+	attribute, err := q.GetAttribute(ctx, geaves.GetAttributeParam{Field: geaves.BySlug, Value: slug})
+	if err != nil {
+		return fmt.Errorf("failed to get attribute %s: %w", slug, err)
+	}
+
+	if err := q.SetItemValue(ctx, itemID, attribute.ID, geaves.Value{Type: t, Data: data}); err != nil {
+		return fmt.Errorf("failed to set %s on item %v: %w", slug, itemID, err)
+	}
+
+	return nil
+}
+
+func geavesUnset(ctx context.Context, q *geaves.Queries, itemID int64, slug string) error {
+	attribute, err := q.GetAttribute(ctx, geaves.GetAttributeParam{Field: geaves.BySlug, Value: slug})
+	if err != nil {
+		return fmt.Errorf("failed to get attribute %s: %w", slug, err)
+	}
+
+	// A value that was never set is already unset
+	if err := q.DeleteItemAttributes(ctx, itemID, attribute.ID); err != nil && !errors.Is(err, geaves.ErrNotFound) {
+		return fmt.Errorf("failed to unset %s on item %v: %w", slug, itemID, err)
+	}
+
+	return nil
+}
+
+`
+
+// goIdent turns a slug into an exported Go identifier, price-per-unit becomes PricePerUnit
+func goIdent(slug string) string {
+	var sb strings.Builder
+	upper := true
+
+	for _, r := range slug {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		sb.WriteRune(r)
+	}
+
+	ident := sb.String()
+	if ident == "" || unicode.IsDigit([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+
+	return ident
+}
+
+func uniqueIdent(ident string, used map[string]bool) string {
+	unique := ident
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s%d", ident, n)
+	}
+
+	used[unique] = true
+	return unique
+}
+
+// uniqueEntityIdent returns ident, numbered when needed, so that the type and every function generated for
+// the entity have names which are not used yet, all of which are then marked as used
+func uniqueEntityIdent(ident string, used map[string]bool) string {
+	isUsed := func(name string) bool { return used[name] }
+
+	unique := ident
+	for n := 2; slices.ContainsFunc(entityIdents(unique), isUsed); n++ {
+		unique = fmt.Sprintf("%s%d", ident, n)
+	}
+
+	for _, name := range entityIdents(unique) {
+		used[name] = true
+	}
+
+	return unique
+}
+
+// entityIdents lists the top level names writeGoEntity declares for an entity named ident
+func entityIdents(ident string) []string {
+	return []string{ident, "New" + ident, "Load" + ident, "load" + ident, "List" + ident + "Items"}
+}
+
+// goParam turns an exported identifier into a parameter name which can not be a keyword
+func goParam(ident string) string {
+	runes := []rune(ident)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes) + "Value"
+}
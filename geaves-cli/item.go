@@ -3,11 +3,16 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/Asfolny/geaves"
 )
@@ -24,7 +29,7 @@ func itemCommand(s state) error {
 		return fmt.Errorf("%s: entity command not found\n", s.args[0])
 	}
 
-	return  cmd.callback(state{s.args[0], s.args[1:], s.queries})
+	return  cmd.callback(state{s.args[0], s.args[1:], s.queries, s.db, s.output, s.tenant})
 }
 
 func getItemCommands() map[string]command {
@@ -69,6 +74,21 @@ func getItemCommands() map[string]command {
 			description: "Delete an item by id",
 			callback: deleteItemCommand,
 		},
+		"export-csv": {
+			name: "item export-csv <entity id|slug> [file] <flags>",
+			description: "Write the items of an entity as csv, one column per attribute",
+			callback: exportCSVItemCommand,
+		},
+		"import-csv": {
+			name: "item import-csv <entity id|slug> <file> <flags>",
+			description: "Create items of an entity from the rows of a csv file",
+			callback: importCSVItemCommand,
+		},
+		"stats": {
+			name: "item stats <entity id|slug> <flags>",
+			description: "Aggregate attribute values over items of an entity",
+			callback: statsItemCommand,
+		},
 		"help": {
 			name: "item help",
 			description: "Displays this help message",
@@ -92,6 +112,10 @@ func createItemCommand(s state) error {
 		return fmt.Errorf("Failed to create item: %w", err)
 	}
 
+	if s.output != outputText {
+		return writeItem(s, item)
+	}
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Successfully created item %v of type %s\n", item.ID, entity.Name))
 	attrs, err := entity.GetAttributes(context.Background(), s.queries)
@@ -126,6 +150,18 @@ func listItemsCommand(s state) error {
 		return err
 	}
 
+	if s.output != outputText {
+		records := make([]itemRecord, len(items))
+		for idx, item := range items {
+			records[idx], err = toItemRecord(item, s.queries)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writeRecords(s, records)
+	}
+
 	var sb strings.Builder
 
 	for _, item := range items {
@@ -144,7 +180,7 @@ func listItemsCommand(s state) error {
 			return fmt.Errorf("Failed to get attributes from entity: %w", err)
 		}
 
-		sb.WriteString(itemToString(item, entity, values, attributes, s.queries))
+		sb.WriteString(itemToString(item, entity, values, attributes))
 
 	}
 
@@ -168,6 +204,10 @@ func infoItemCommand(s state) error {
 		return fmt.Errorf("Failed to get item: %w", err)
 	}
 
+	if s.output != outputText {
+		return writeItem(s, item)
+	}
+
 	entity, err := s.queries.GetEntity(context.Background(), geaves.GetEntityParam{WithAttributes: true, Field: geaves.ByID, Value: item.EntityID})
 	if err != nil {
 		return fmt.Errorf("Failed to get entity for item: %w", err)
@@ -184,7 +224,7 @@ func infoItemCommand(s state) error {
 	}
 
 	var sb strings.Builder
-	sb.WriteString(itemToString(item, entity, values, attributes, s.queries))
+	sb.WriteString(itemToString(item, entity, values, attributes))
 	sb.WriteString(fmt.Sprintln("* are required"))
 	fmt.Print(sb.String())
 	return nil
@@ -215,6 +255,11 @@ func updateItemCommand(s state) error {
 		return fmt.Errorf("Failed changing to entity (%s) on item: %w", entity.Name, err)
 	}
 
+	if s.output != outputText {
+		item.EntityID = entity.ID
+		return writeItem(s, item)
+	}
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Successfully created item %v of type %s\n", item.ID, entity.Name))
 	attrs, err := entity.GetAttributes(context.Background(), s.queries)
@@ -253,443 +298,407 @@ func deleteItemCommand(s state) error {
 		return fmt.Errorf("Failed to convert id to int64: %w", err)
 	}
 
-	err = s.queries.DeleteItemAttributesByItem(context.Background(), id)
+	item, err := s.queries.GetItem(context.Background(), id)
 	if err != nil {
-		return fmt.Errorf("Failed to delete item attributes: %w", err)
-	}
-
-	err = s.queries.DeleteItem(context.Background(), id)
-	if err == nil {
-		fmt.Println("Successfully deleted item and item attributes")
+		return fmt.Errorf("Failed to get item: %w", err)
 	}
 
-	return err
-}
-
-func itemAddAttributeCommand(s state) error {
-	if len(s.args) < 3 {
-		return fmt.Errorf("%s required 3 arguments, the item id, the attribute id or slug and the new value", s.cmdName)
+	// The record is read before deleting, with the values deleted along with the item
+	record, err := toItemRecord(item, s.queries)
+	if err != nil {
+		return err
 	}
 
-	id, err := strconv.ParseInt(s.args[0], 10, 64)
+	err = s.queries.DeleteItemAttributesByItem(context.Background(), id)
 	if err != nil {
-		return fmt.Errorf("Failed to convert id to int64: %w", err)
+		return fmt.Errorf("Failed to delete item attributes: %w", err)
 	}
 
-	item, err := s.queries.GetItem(context.Background(), id)
+	err = s.queries.DeleteItem(context.Background(), id)
 	if err != nil {
-		return fmt.Errorf("Failed to get item: %w", err)
+		return err
 	}
 
-	attribute, err := getAttributeByIdOrSlug(s.args[1], false, s.queries)
-	if err != nil {
-		return fmt.Errorf("Failed to get attribute: %w", err)
+	if s.output != outputText {
+		return writeRecord(s, record)
 	}
 
-	// TODO WARN user when value is not compatible with type
-	switch (geaves.AttributeType(attribute.Type)) {
-	case geaves.BoolType:
-		var value *bool
-		p, err := strconv.ParseBool(s.args[2])
-		if err == nil {
-			value = &p
-		}
+	fmt.Println("Successfully deleted item and item attributes")
+	return nil
+}
 
-		itemAttribute := geaves.ItemAttribute[*bool]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+func statsItemCommand(s state) error {
+	statsFs := flag.NewFlagSet("item", flag.ContinueOnError)
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	var aggs stringsFlag
+	var by stringsFlag
+	var wheres []string
 
+	statsFs.Var(&aggs, "agg", "Aggregate to take, <function>:<attribute slug>")
+	statsFs.Var(&aggs, "a", "Aggregate to take, <function>:<attribute slug> (shorthand)")
 
-	case geaves.StringType:
-		value := s.args[2]
-		itemAttribute := geaves.ItemAttribute[*string]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &value,
-		}
+	statsFs.Var(&by, "by", "Attribute slug to group by")
+	statsFs.Var(&by, "b", "Attribute slug to group by (shorthand)")
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	// Not a stringsFlag, values may hold commas
+	addWhere := func(where string) error {
+		wheres = append(wheres, where)
+		return nil
+	}
+	statsFs.Func("where", "Only aggregate items matching <attribute slug>:<operator>:<value>", addWhere)
+	statsFs.Func("w", "Only aggregate items matching <attribute slug>:<operator>:<value> (shorthand)", addWhere)
 
+	args, err := parseInterspersed(statsFs, s.args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("%s requires an argument, the slug or id of the entity to aggregate items of", s.cmdName)
+	}
+	search := args[0]
 
-	case geaves.IntType:
-		var value *int
-		num, err := strconv.ParseInt(s.args[2], 10, 0)
-		if err == nil {
-			lowNum := int(num)
-			value = &lowNum
-		}
+	if len(aggs) == 0 {
+		return fmt.Errorf("%s requires the --agg flag, see item help stats", s.cmdName)
+	}
+	if len(aggs) > 1 {
+		return fmt.Errorf("%s takes one --agg, got %s", s.cmdName, strings.Join(aggs, ", "))
+	}
+	agg := aggs[0]
 
-		itemAttribute := geaves.ItemAttribute[*int]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	funcName, slug, _ := strings.Cut(agg, ":")
+	if !geaves.ValidAggregateFunc(funcName) {
+		return fmt.Errorf("'%s' is not a valid aggregate function, use one of count, count-distinct, sum, avg, min, max", funcName)
+	}
 
+	entity, err := getEntityByIdOrSlug(search, false, s.queries)
+	if err != nil {
+		return fmt.Errorf("Failed to get entity: %w", err)
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
+	preds := make([]geaves.Predicate, len(wheres))
+	for idx, where := range wheres {
+		preds[idx], err = parseWhere(s, &entity, where)
 		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
+			return err
 		}
-		break
+	}
 
+	rows, err := s.queries.Aggregate(context.Background(), geaves.AggregateQuery{
+		EntityID: entity.ID,
+		Func: geaves.AggregateFunc(funcName),
+		Attribute: slug,
+		GroupBy: by,
+		Where: preds,
+	})
+	if err != nil {
+		return err
+	}
 
-	case geaves.Int8Type:
-		var value *int8
-		num, err := strconv.ParseInt(s.args[2], 10, 8)
-		if err == nil {
-			lowNum := int8(num)
-			value = &lowNum
-		}
+	if s.output != outputText {
+		records := make([]statsRecord, len(rows))
+		for idx, row := range rows {
+			records[idx].Group = map[string]json.RawMessage{}
+			for groupIdx, group := range row.Group {
+				if records[idx].Group[by[groupIdx]], err = json.Marshal(group); err != nil {
+					return fmt.Errorf("Failed to write %s group value: %w", by[groupIdx], err)
+				}
+			}
 
-		itemAttribute := geaves.ItemAttribute[*int8]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
+			if records[idx].Value, err = json.Marshal(row.Value); err != nil {
+				return fmt.Errorf("Failed to write %s: %w", agg, err)
+			}
 		}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+		return writeRecords(s, records)
+	}
 
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\n", strings.Repeat("-", 20)))
+	sb.WriteString(fmt.Sprintf("| %s of %s (%s)\n", agg, entity.Name, entity.Slug))
+	sb.WriteString(fmt.Sprintf("%s\n", strings.Repeat("-", 20)))
 
-	case geaves.Int16Type:
-		var value *int16
-		num, err := strconv.ParseInt(s.args[2], 10, 16)
-		if err == nil {
-			lowNum := int16(num)
-			value = &lowNum
+	for _, row := range rows {
+		groups := make([]string, len(row.Group))
+		for idx, group := range row.Group {
+			groups[idx] = fmt.Sprintf("%s=%s", by[idx], valueToString(group))
 		}
 
-		itemAttribute := geaves.ItemAttribute[*int16]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
+		if len(groups) > 0 {
+			sb.WriteString(fmt.Sprintf("|  %s: %s\n", strings.Join(groups, ", "), valueToString(row.Value)))
+		} else {
+			sb.WriteString(fmt.Sprintf("|  %s\n", valueToString(row.Value)))
 		}
+	}
 
+	sb.WriteString(fmt.Sprintf("%s\n", strings.Repeat("-", 20)))
+	fmt.Print(sb.String())
+	return nil
+}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+// parseWhere reads a --where flag of <attribute slug>:<operator>:<value> into a predicate on an attribute of entity,
+// the value is in the format of geaves.ParseValue, <from>..<to> for between with either end left out when open,
+// and left out for is-null and not-null
+func parseWhere(s state, entity *geaves.Entity, where string) (geaves.Predicate, error) {
+	slug, rest, _ := strings.Cut(where, ":")
+	op, text, hasValue := strings.Cut(rest, ":")
+	pred := geaves.Predicate{Attribute: slug, Op: geaves.Operator(op)}
 
+	attributes, err := entity.GetAttributes(context.Background(), s.queries)
+	if err != nil {
+		return pred, fmt.Errorf("Failed to get attributes of entity: %w", err)
+	}
 
-	case geaves.Int32Type:
-		var value *int32
-		num, err := strconv.ParseInt(s.args[2], 10, 32)
-		if err == nil {
-			lowNum := int32(num)
-			value = &lowNum
-		}
+	idx := slices.IndexFunc(attributes, func(a geaves.EntityAttributeEmbed) bool { return a.Slug == slug })
+	if idx < 0 {
+		return pred, &geaves.ValidationError{Field: slug, Err: fmt.Errorf("%w: not linked to %s", geaves.ErrNotFound, entity.Slug)}
+	}
+	attribute := attributes[idx].Attribute
 
-		itemAttribute := geaves.ItemAttribute[*int32]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
+	switch pred.Op {
+	case geaves.OpIsNull, geaves.OpNotNull:
+		return pred, nil
+	case geaves.OpEq, geaves.OpNe, geaves.OpLt, geaves.OpLe, geaves.OpGt, geaves.OpGe:
+		if !hasValue {
+			return pred, fmt.Errorf("--where %s needs a value, written as <attribute slug>:<operator>:<value>", where)
 		}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
+		value, err := parseValueArg(attribute, text)
 		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
+			return pred, err
 		}
-		break
 
-
-	case geaves.Int64Type:
-		var value *int64
-		num, err := strconv.ParseInt(s.args[2], 10, 64)
-		if err == nil {
-			value = &num
-		}
-
-		itemAttribute := geaves.ItemAttribute[*int64]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
+		pred.Value = value.Data
+		return pred, nil
+	case geaves.OpBetween:
+		from, to, ok := strings.Cut(text, "..")
+		if !ok || (from == "" && to == "") {
+			return pred, fmt.Errorf("--where %s needs a range, written as <attribute slug>:between:<from>..<to>", where)
 		}
-		break
 
+		var r geaves.Range
+		for _, end := range []struct{ text string; value *any }{{from, &r.From}, {to, &r.To}} {
+			if end.text == "" {
+				continue
+			}
 
-	case geaves.UintType:
-		var value *uint
-		num, err := strconv.ParseUint(s.args[2], 10, 0)
-		if err == nil {
-			lowNum := uint(num)
-			value = &lowNum
+			value, err := parseValueArg(attribute, end.text)
+			if err != nil {
+				return pred, err
+			}
+			*end.value = value.Data
 		}
 
-		itemAttribute := geaves.ItemAttribute[*uint]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+		pred.Value = r
+		return pred, nil
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	return pred, fmt.Errorf("'%s' is not a valid operator, use one of =, !=, <, <=, >, >=, is-null, not-null, between", op)
+}
 
+func exportCSVItemCommand(s state) error {
+	exportFs := flag.NewFlagSet("item", flag.ContinueOnError)
 
-	case geaves.Uint8Type:
-		var value *uint8
-		num, err := strconv.ParseUint(s.args[2], 10, 8)
-		if err == nil {
-			lowNum := uint8(num)
-			value = &lowNum
-		}
+	var columns stringsFlag
+	var tsv bool
 
-		itemAttribute := geaves.ItemAttribute[*uint8]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	exportFs.Var(&columns, "columns", "Attribute slugs to write, in order")
+	exportFs.Var(&columns, "c", "Attribute slugs to write, in order (shorthand)")
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	exportFs.BoolVar(&tsv, "tsv", false, "Write tab separated values")
+	exportFs.BoolVar(&tsv, "t", false, "Write tab separated values (shorthand)")
 
+	args, err := parseInterspersed(exportFs, s.args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("%s requires an argument, the entity id or slug", s.cmdName)
+	}
 
-	case geaves.Uint16Type:
-		var value *uint16
-		num, err := strconv.ParseUint(s.args[2], 10, 16)
-		if err == nil {
-			lowNum := uint16(num)
-			value = &lowNum
-		}
+	entity, err := getEntityByIdOrSlug(args[0], false, s.queries)
+	if err != nil {
+		return fmt.Errorf("Failed to get entity: %w", err)
+	}
 
-		itemAttribute := geaves.ItemAttribute[*uint16]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	opts := geaves.CSVExportOptions{Columns: columns}
+	if tsv {
+		opts.Comma = '\t'
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
+	var w io.Writer = os.Stdout
+	if len(args) >= 2 && args[1] != "-" {
+		f, err := os.Create(args[1])
 		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
+			return fmt.Errorf("Failed to create csv file: %w", err)
 		}
-		break
-
+		defer f.Close()
 
-	case geaves.Uint32Type:
-		var value *uint32
-		num, err := strconv.ParseUint(s.args[2], 10, 32)
-		if err == nil {
-			lowNum := uint32(num)
-			value = &lowNum
-		}
+		w = f
+	}
 
-		itemAttribute := geaves.ItemAttribute[*uint32]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	return geaves.ExportCSV(context.Background(), s.queries, entity.ID, w, opts)
+}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+func importCSVItemCommand(s state) error {
+	importFs := flag.NewFlagSet("item", flag.ContinueOnError)
 
+	var mapFile string
+	var createMissing bool
+	var tsv bool
 
-	case geaves.Uint64Type:
-		var value *uint64
-		num, err := strconv.ParseUint(s.args[2], 10, 64)
-		if err == nil {
-			value = &num
-		}
+	importFs.StringVar(&mapFile, "map", "", "csv file mapping header columns to attribute slugs")
+	importFs.StringVar(&mapFile, "m", "", "csv file mapping header columns to attribute slugs (shorthand)")
 
-		itemAttribute := geaves.ItemAttribute[*uint64]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	importFs.BoolVar(&createMissing, "create-missing", false, "Create and link missing attributes")
+	importFs.BoolVar(&createMissing, "c", false, "Create and link missing attributes (shorthand)")
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	importFs.BoolVar(&tsv, "tsv", false, "Read tab separated values")
+	importFs.BoolVar(&tsv, "t", false, "Read tab separated values (shorthand)")
 
+	args, err := parseInterspersed(importFs, s.args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("%s requires 2 arguments, the entity id or slug and the csv file", s.cmdName)
+	}
 
-	case geaves.ByteType:
-		bytes := []byte(s.args[2])
+	entity, err := getEntityByIdOrSlug(args[0], false, s.queries)
+	if err != nil {
+		return fmt.Errorf("Failed to get entity: %w", err)
+	}
 
-		itemAttribute := geaves.ItemAttribute[*byte]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &bytes[0],
-		}
+	opts := geaves.CSVImportOptions{CreateMissing: createMissing}
+	if tsv {
+		opts.Comma = '\t'
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
+	if mapFile != "" {
+		opts.Mapping, err = readMappingFile(mapFile)
 		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-
-	case geaves.RuneType:
-		runes := []rune(s.args[2])
-
-		itemAttribute := geaves.ItemAttribute[*rune]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &runes[0],
+			return err
 		}
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
+	var r io.Reader = os.Stdin
+	if args[1] != "-" {
+		f, err := os.Open(args[1])
 		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
+			return fmt.Errorf("Failed to open csv file: %w", err)
 		}
-		break
-
+		defer f.Close()
 
-	case geaves.Float32Type:
-		var value *float32
-		num, err := strconv.ParseFloat(s.args[2], 32)
-		if err == nil {
-			lowNum := float32(num)
-			value = &lowNum
-		}
+		r = f
+	}
 
-		itemAttribute := geaves.ItemAttribute[*float32]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	res, err := geaves.ImportCSV(context.Background(), s.queries, entity.ID, r, opts)
+	if err != nil {
+		return err
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	if s.output != outputText {
+		return writeRecord(s, csvImportRecord{
+			Entity: entity.Slug,
+			ItemIDs: orEmpty(res.ItemIDs),
+			AttributesCreated: orEmpty(res.AttributesCreated),
+			AttributesLinked: orEmpty(res.AttributesLinked),
+		})
+	}
 
+	var sb strings.Builder
+	for _, slug := range res.AttributesCreated {
+		sb.WriteString(fmt.Sprintf("Created attribute %s (string)\n", slug))
+	}
 
-	case geaves.Float64Type:
-		var value *float64
-		num, err := strconv.ParseFloat(s.args[2], 64)
-		if err == nil {
-			value = &num
-		}
+	for _, slug := range res.AttributesLinked {
+		sb.WriteString(fmt.Sprintf("Linked attribute %s to %s\n", slug, entity.Name))
+	}
 
-		itemAttribute := geaves.ItemAttribute[*float64]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	sb.WriteString(fmt.Sprintf("Successfully imported %v items of type %s\n", len(res.ItemIDs), entity.Name))
+	fmt.Print(sb.String())
+	return nil
+}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+func readMappingFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open mapping file: %w", err)
+	}
+	defer f.Close()
 
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 2
 
-	case geaves.BlobType:
-		bytes := []byte(s.args[2])
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read mapping file: %w", err)
+	}
 
-		itemAttribute := geaves.ItemAttribute[*[]byte]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &bytes,
-		}
+	mapping := make(map[string]string, len(records))
+	for _, record := range records {
+		mapping[record[0]] = record[1]
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	return mapping, nil
+}
 
+func valueToString(value geaves.Value) string {
+	if value.IsNull() {
+		return "nil"
+	}
 
-	case geaves.DateType:
-		var value *time.Time
-		t, err := time.Parse("2006-01-02", s.args[2])
-		if err == nil {
-			value = &t
-		}
+	return geaves.FormatValue(value)
+}
 
-		itemAttribute := geaves.ItemAttribute[*time.Time]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+// parseValueArg reads a value given on the command line for attribute, in the format of geaves.ParseValue
+func parseValueArg(attribute geaves.Attribute, text string) (geaves.Value, error) {
+	value, err := geaves.ParseValue(attribute.Type, text)
+	if err != nil {
+		return value, &geaves.ValidationError{Field: attribute.Slug, Value: text, Err: fmt.Errorf("%w: %w", geaves.ErrInvalidType, err)}
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	return value, nil
+}
 
-	case geaves.TimeType:
-		var value *time.Time
-		t, err := time.Parse("15:04:05", s.args[2])
-		if err == nil {
-			value = &t
-		}
+func itemAddAttributeCommand(s state) error {
+	if len(s.args) < 3 {
+		return fmt.Errorf("%s required 3 arguments, the item id, the attribute id or slug and the new value", s.cmdName)
+	}
 
-		itemAttribute := geaves.ItemAttribute[*time.Time]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	id, err := strconv.ParseInt(s.args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("Failed to convert id to int64: %w", err)
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	item, err := s.queries.GetItem(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("Failed to get item: %w", err)
+	}
 
-	case geaves.DatetimeType:
-		var value *time.Time
-		t, err := time.Parse("2006-01-02 15:04:05", s.args[2])
-		if err == nil {
-			value = &t
-		}
+	attribute, err := getAttributeByIdOrSlug(s.args[1], false, s.queries)
+	if err != nil {
+		return fmt.Errorf("Failed to get attribute: %w", err)
+	}
 
-		itemAttribute := geaves.ItemAttribute[*time.Time]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	value, err := parseValueArg(attribute, s.args[2])
+	if err != nil {
+		return err
+	}
 
-		err = itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	itemAttribute := geaves.ItemAttribute[any]{
+		ItemID: item.ID,
+		AttributeID: attribute.ID,
+		Type: attribute.Type,
+		Value: value.Data,
+	}
 
-	default:
-		itemAttribute := geaves.ItemAttribute[*any]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: nil,
-		}
+	err = itemAttribute.Create(context.Background(), s.queries)
+	if err != nil {
+		return fmt.Errorf("failed to create item_attribute record: %w", err)
+	}
 
-		err := itemAttribute.Create(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
+	if s.output != outputText {
+		return writeItemValue(s, item.ID, attribute)
 	}
 
 	fmt.Println("Succesfully added new item attribute value to system")
@@ -716,433 +725,64 @@ func itemSetAttributeCommand(s state) error {
 		return fmt.Errorf("Failed to get attribute: %w", err)
 	}
 
-	// TODO WARN user when value is not compatible with type
-	switch (geaves.AttributeType(attribute.Type)) {
-	case geaves.BoolType:
-		var value *bool
-		p, err := strconv.ParseBool(s.args[2])
-		if err == nil {
-			value = &p
-		}
+	value, err := parseValueArg(attribute, s.args[2])
+	if err != nil {
+		return err
+	}
 
-		itemAttribute := geaves.ItemAttribute[*bool]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	itemAttribute := geaves.ItemAttribute[any]{
+		ItemID: item.ID,
+		AttributeID: attribute.ID,
+		Type: attribute.Type,
+		Value: value.Data,
+	}
 
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+	err = itemAttribute.Update(context.Background(), s.queries)
+	if err != nil {
+		return fmt.Errorf("failed to update item_attribute record: %w", err)
+	}
 
+	if s.output != outputText {
+		return writeItemValue(s, item.ID, attribute)
+	}
 
-	case geaves.StringType:
-		value := s.args[2]
-		itemAttribute := geaves.ItemAttribute[*string]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &value,
-		}
+	fmt.Println("succesfully updated attribute on item")
+	return nil
+}
 
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
+func itemDelAttributeCommand(s state) error {
+	if len(s.args) < 2 {
+		return fmt.Errorf("%s required 2 arguments, the item id and attribute id to delete item attribute", s.cmdName)
+	}
 
+	id, err := strconv.ParseInt(s.args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("Failed to convert id to int64: %w", err)
+	}
 
-	case geaves.IntType:
-		var value *int
-		num, err := strconv.ParseInt(s.args[2], 10, 0)
-		if err == nil {
-			lowNum := int(num)
-			value = &lowNum
-		}
+	item, err := s.queries.GetItem(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("Failed to get item: %w", err)
+	}
 
-		itemAttribute := geaves.ItemAttribute[*int]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
+	attribute, err := getAttributeByIdOrSlug(s.args[1], false, s.queries)
+	if err != nil {
+		return fmt.Errorf("Failed to get attribute: %w", err)
+	}
 
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
 
+	err = s.queries.DeleteItemAttributes(context.Background(), item.ID, attribute.ID)
+	if err != nil {
+		return err
+	}
 
-	case geaves.Int8Type:
-		var value *int8
-		num, err := strconv.ParseInt(s.args[2], 10, 8)
-		if err == nil {
-			lowNum := int8(num)
-			value = &lowNum
-		}
+	if s.output != outputText {
+		return writeItemValue(s, item.ID, attribute)
+	}
 
-		itemAttribute := geaves.ItemAttribute[*int8]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-
-	case geaves.Int16Type:
-		var value *int16
-		num, err := strconv.ParseInt(s.args[2], 10, 16)
-		if err == nil {
-			lowNum := int16(num)
-			value = &lowNum
-		}
-
-		itemAttribute := geaves.ItemAttribute[*int16]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Int32Type:
-		var value *int32
-		num, err := strconv.ParseInt(s.args[2], 10, 32)
-		if err == nil {
-			lowNum := int32(num)
-			value = &lowNum
-		}
-
-		itemAttribute := geaves.ItemAttribute[*int32]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Int64Type:
-		var value *int64
-		num, err := strconv.ParseInt(s.args[2], 10, 64)
-		if err == nil {
-			value = &num
-		}
-
-		itemAttribute := geaves.ItemAttribute[*int64]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.UintType:
-		var value *uint
-		num, err := strconv.ParseUint(s.args[2], 10, 0)
-		if err == nil {
-			lowNum := uint(num)
-			value = &lowNum
-		}
-
-		itemAttribute := geaves.ItemAttribute[*uint]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Uint8Type:
-		var value *uint8
-		num, err := strconv.ParseUint(s.args[2], 10, 8)
-		if err == nil {
-			lowNum := uint8(num)
-			value = &lowNum
-		}
-
-		itemAttribute := geaves.ItemAttribute[*uint8]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Uint16Type:
-		var value *uint16
-		num, err := strconv.ParseUint(s.args[2], 10, 16)
-		if err == nil {
-			lowNum := uint16(num)
-			value = &lowNum
-		}
-
-		itemAttribute := geaves.ItemAttribute[*uint16]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Uint32Type:
-		var value *uint32
-		num, err := strconv.ParseUint(s.args[2], 10, 32)
-		if err == nil {
-			lowNum := uint32(num)
-			value = &lowNum
-		}
-
-		itemAttribute := geaves.ItemAttribute[*uint32]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Uint64Type:
-		var value *uint64
-		num, err := strconv.ParseUint(s.args[2], 10, 64)
-		if err == nil {
-			value = &num
-		}
-
-		itemAttribute := geaves.ItemAttribute[*uint64]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.ByteType:
-		bytes := []byte(s.args[2])
-
-		itemAttribute := geaves.ItemAttribute[*byte]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &bytes[0],
-		}
-
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.RuneType:
-		runes := []rune(s.args[2])
-
-		itemAttribute := geaves.ItemAttribute[*rune]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &runes[0],
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Float32Type:
-		var value *float32
-		num, err := strconv.ParseFloat(s.args[2], 32)
-		if err == nil {
-			lowNum := float32(num)
-			value = &lowNum
-		}
-
-		itemAttribute := geaves.ItemAttribute[*float32]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.Float64Type:
-		var value *float64
-		num, err := strconv.ParseFloat(s.args[2], 64)
-		if err == nil {
-			value = &num
-		}
-
-		itemAttribute := geaves.ItemAttribute[*float64]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.BlobType:
-		bytes := []byte(s.args[2])
-
-		itemAttribute := geaves.ItemAttribute[*[]byte]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: &bytes,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.DateType:
-		var value *time.Time
-		t, err := time.Parse("2006-01-02", s.args[2])
-		if err == nil {
-			value = &t
-		}
-
-		itemAttribute := geaves.ItemAttribute[*time.Time]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.TimeType:
-		var value *time.Time
-		t, err := time.Parse("15:04:05", s.args[2])
-		if err == nil {
-			value = &t
-		}
-
-		itemAttribute := geaves.ItemAttribute[*time.Time]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	case geaves.DatetimeType:
-		var value *time.Time
-		t, err := time.Parse("2006-01-02 15:04:05", s.args[2])
-		fmt.Printf("%v\n%v\n", err, s.args[2])
-		if err == nil {
-			value = &t
-		}
-
-		itemAttribute := geaves.ItemAttribute[*time.Time]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: value,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-		break
-
-	default:
-		itemAttribute := geaves.ItemAttribute[*any]{
-			ItemID: item.ID,
-			AttributeID: attribute.ID,
-			Value: nil,
-		}
-
-		err = itemAttribute.Update(context.Background(), s.queries)
-		if err != nil {
-			return fmt.Errorf("failed to create item_attribute record: %w", err)
-		}
-	}
-
-	fmt.Println("succesfully updated attribute on item")
-	return nil
-}
-
-func itemDelAttributeCommand(s state) error {
-	if len(s.args) < 2 {
-		return fmt.Errorf("%s required 2 arguments, the item id and attribute id to delete item attribute", s.cmdName)
-	}
-
-	id, err := strconv.ParseInt(s.args[0], 10, 64)
-	if err != nil {
-		return fmt.Errorf("Failed to convert id to int64: %w", err)
-	}
-
-	item, err := s.queries.GetItem(context.Background(), id)
-	if err != nil {
-		return fmt.Errorf("Failed to get item: %w", err)
-	}
-
-	attribute, err := getAttributeByIdOrSlug(s.args[1], false, s.queries)
-	if err != nil {
-		return fmt.Errorf("Failed to get attribute: %w", err)
-	}
-
-
-	err = s.queries.DeleteItemAttributes(context.Background(), item.ID, attribute.ID)
-	if err == nil {
-		fmt.Println("Succesfully delete item's attribute value")
-	}
-
-	return err
-}
+	fmt.Println("Succesfully delete item's attribute value")
+	return nil
+}
 
 func helpItemCommand(s state) (err error) {
 	if len(s.args) > 0 {
@@ -1165,7 +805,19 @@ Change an item's entity using a provided entity id or entity slug value
 			fmt.Print(`
 geaves-cli item add <item id> <attribute id|slug> <value>
 
-Add a new item attribute value to the system using a provided item id, attribute id or attribute slug and value which can by any type
+Add a new item attribute value to the system using a provided item id, attribute id or attribute slug and value
+
+The value is written in the format of the attribute's type, a value that does not parse is refused
+  bool                          - true or false (also 1, 0, t, f)
+  int, uint and their sizes     - base 10, like -42, in range of the type
+  float32, float64              - base 10 with a '.' for decimals, like 1.5 or 1e-3
+  byte, rune                    - exactly one character
+  blob                          - base64, like aGVsbG8=
+  date                          - 2006-01-02
+  time                          - 15:04:05, optionally with fractions of a second
+  datetime                      - RFC 3339 like 2006-01-02T15:04:05Z, or 2006-01-02 15:04:05 read as UTC
+  decimal(p,s)                  - like -12.30, with at most s digits after the point
+  money(p,s)                    - a decimal and its currency code, like 12.30 EUR
 `)
 			return
 		case "del":
@@ -1177,9 +829,10 @@ Remove an item's attribute value using the provided item id and attribute id or
 			return
 		case "set":
 			fmt.Print(`
-geaves-cli item list <item id> <attribute id|slug> <value>
+geaves-cli item set <item id> <attribute id|slug> <value>
 
-Update an item's attribute value using the provided item id, attribute id or attribute slug and new value of same type
+Update an item's attribute value using the provided item id, attribute id or attribute slug and new value of same type,
+written in the format described in item help add
 `)
 			return
 		case "list":
@@ -1201,6 +854,56 @@ Print item details to screen
 geaves-cli item delete <item id>
 
 Delete an item by the provided item id
+`)
+			return
+		case "export-csv":
+			fmt.Print(`
+geaves-cli item export-csv <entity id|slug> [file] <flags>
+
+Write every item of an entity as csv, to file or stdout when no file (or -) is given
+The first column is the item id, followed by a column per attribute with required attributes first
+Values are written in the same formats as item info, so the file can be read back by item import-csv
+Empty strings and missing values are both written as an empty cell, import-csv reads them back as missing
+
+Available flags
+  -c | --columns  - attribute slugs to write, in order, may be given multiple times or comma separated
+  -t | --tsv      - write tab separated values instead
+`)
+			return
+		case "import-csv":
+			fmt.Print(`
+geaves-cli item import-csv <entity id|slug> <file> <flags>
+
+Create an item of the entity per row of a csv file, use - as file to read stdin
+The header row names the attribute slug of each column, empty cells are left without a value
+Cells are parsed by the type of their attribute, see item help add for the formats
+A column named id without an attribute is skipped, so files from item export-csv can be imported
+Every row is checked before anything is created, if any row fails nothing is imported and all failing rows are listed
+
+Available flags
+  -m | --map             - csv file of header,slug rows mapping columns to attributes, map to an empty slug to skip a column
+  -c | --create-missing  - create string attributes for columns with no attribute, and link attributes not linked to the entity
+  -t | --tsv             - read tab separated values instead
+`)
+			return
+		case "stats":
+			fmt.Print(`
+geaves-cli item stats <entity id|slug> <flags>
+
+Aggregate the values of an attribute over all items of an entity, optionally grouped by the values of other attributes
+
+  -a | --agg    - the aggregate to take, written as <function>:<attribute slug>, or just count to count items, given once
+  -a | --agg  - the aggregate to take, written as <function>:<attribute slug>, or just count to count items
+
+Available flags
+  -b | --by     - attribute slug to group by, may be given multiple times or comma separated
+  -w | --where  - only aggregate items matching <attribute slug>:<operator>:<value>, may be given multiple times
+
+Function MUST be one of count, count-distinct, sum, avg, min, max
+sum and avg only work on numeric attributes, min and max do not work on blob attributes
+
+Operator MUST be one of =, !=, <, <=, >, >=, is-null, not-null, between
+is-null and not-null take no value, between takes <from>..<to> with either end left out when open
 `)
 			return
 		default:
@@ -1222,12 +925,35 @@ Available subcommands
   list                                      - prints all items
   info <id>                                 - prints item details by id
   delete <id>                               - delete an item by id
+  export-csv <entity id|slug> [file] <flags> - write the items of an entity as csv
+  import-csv <entity id|slug> <file> <flags> - create items from the rows of a csv file
+  stats <entity id|slug> <flags>            - aggregate attribute values over items of an entity
   help [subcommand]                         - prints this message or the help info on a subcommand
 \n`)
 	return
 }
 
-func itemToString(item geaves.Item, entity geaves.Entity, itemAttributes []geaves.ItemAttribute[*any], attributes []geaves.EntityAttributeEmbed, queries *geaves.Queries) string  {
+// writeItem writes an item with its values in the output format of s
+func writeItem(s state, item geaves.Item) error {
+	record, err := toItemRecord(item, s.queries)
+	if err != nil {
+		return err
+	}
+
+	return writeRecord(s, record)
+}
+
+// writeItemValue writes the value attribute has on an item after add, set or del
+func writeItemValue(s state, itemId int64, attribute geaves.Attribute) error {
+	record, err := toItemValueRecord(itemId, attribute, s.queries)
+	if err != nil {
+		return err
+	}
+
+	return writeRecord(s, record)
+}
+
+func itemToString(item geaves.Item, entity geaves.Entity, itemAttributes []geaves.ItemAttribute[*any], attributes []geaves.EntityAttributeEmbed) string  {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("%s\n", strings.Repeat("-", 20)))
@@ -1257,413 +983,11 @@ func itemToString(item geaves.Item, entity geaves.Entity, itemAttributes []geave
 		}
 
 		var value any
-
-		switch (geaves.AttributeType(attribute.Type)) {
-		case geaves.BoolType:
-			itemAttribute := geaves.ItemAttribute[*bool]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.StringType:
-			itemAttribute := geaves.ItemAttribute[*string]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-
-			break
-
-
-		case geaves.IntType:
-			itemAttribute := geaves.ItemAttribute[*int]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Int8Type:
-			itemAttribute := geaves.ItemAttribute[*int8]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Int16Type:
-			itemAttribute := geaves.ItemAttribute[*int16]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Int32Type:
-			itemAttribute := geaves.ItemAttribute[*int32]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Int64Type:
-			itemAttribute := geaves.ItemAttribute[*int64]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.UintType:
-			itemAttribute := geaves.ItemAttribute[*uint]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Uint8Type:
-			itemAttribute := geaves.ItemAttribute[*uint8]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Uint16Type:
-			itemAttribute := geaves.ItemAttribute[*uint16]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Uint32Type:
-			itemAttribute := geaves.ItemAttribute[*uint32]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Uint64Type:
-			itemAttribute := geaves.ItemAttribute[*uint64]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.ByteType:
-			itemAttribute := geaves.ItemAttribute[*byte]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.RuneType:
-			itemAttribute := geaves.ItemAttribute[*rune]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Float32Type:
-			itemAttribute := geaves.ItemAttribute[*float32]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.Float64Type:
-			itemAttribute := geaves.ItemAttribute[*float64]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.BlobType:
-			itemAttribute := geaves.ItemAttribute[*[]byte]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.DateType:
-			itemAttribute := geaves.ItemAttribute[*time.Time]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.TimeType:
-			itemAttribute := geaves.ItemAttribute[*time.Time]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		case geaves.DatetimeType:
-			itemAttribute := geaves.ItemAttribute[*time.Time]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-				Type: attribute.Type,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", itemAttribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
-			break
-
-		default:
-			itemAttribute := geaves.ItemAttribute[*any]{
-				ItemID: item.ID,
-				AttributeID: attribute.ID,
-			}
-
-			err := itemAttribute.Load(context.Background(), queries)
-			if err != nil {
-				sb.WriteString(fmt.Sprintf("|  Failed to load value of %s: %v\n", attribute.Type, err))
-			}
-
-			if itemAttribute.Value == nil {
-				value = nil
-			} else {
-				value = *itemAttribute.Value
-			}
+		if itemAttribute.Value != nil {
+			value = *itemAttribute.Value
 		}
 
-		var valStr string
-		if value == nil {
-			valStr = "nil"
-		} else {
-			valStr = fmt.Sprintf("%v", value)
-		}
+		valStr := valueToString(geaves.Value{Type: attribute.Type, Data: value})
 
 		sb.WriteString(fmt.Sprintf("|  %s%s: %v\n", reqString, attribute.Name, valStr))
 	}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineReader reads the lines of the shell
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// completeFunc returns the candidates for the word being typed at the end of line
+type completeFunc func(line string) []string
+
+// errInterrupt is returned by ReadLine when the line is abandoned with ctrl+c
+var errInterrupt = errors.New("interrupted")
+
+// plainReader reads lines without editing, for input that is not a terminal
+type plainReader struct {
+	r *bufio.Reader
+	prompt bool
+}
+
+func (p *plainReader) ReadLine(prompt string) (string, error) {
+	if p.prompt {
+		fmt.Print(prompt)
+	}
+
+	line, err := p.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// editor edits a line in a terminal in raw mode, with history on up and down and completion on tab
+type editor struct {
+	r *bufio.Reader
+	history *[]string
+	complete completeFunc
+	// setRaw puts the terminal in raw mode and returns the function restoring it
+	setRaw func() (func(), error)
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	restore, err := e.setRaw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	var buf []rune
+	pos := 0
+	histPos := len(*e.history)
+	var pending []rune
+
+	redraw := func() {
+		fmt.Printf("\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Printf("\x1b[%dD", back)
+		}
+	}
+
+	insert := func(rs []rune) {
+		buf = append(buf[:pos], append(rs, buf[pos:]...)...)
+		pos += len(rs)
+	}
+
+	fmt.Print(prompt)
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Print("\r\n")
+			return string(buf), nil
+		case 3: // ctrl+c
+			fmt.Print("^C\r\n")
+			return "", errInterrupt
+		case 4: // ctrl+d
+			if len(buf) == 0 {
+				fmt.Print("\r\n")
+				return "", io.EOF
+			}
+		case 1: // ctrl+a
+			pos = 0
+		case 5: // ctrl+e
+			pos = len(buf)
+		case 21: // ctrl+u
+			buf = buf[pos:]
+			pos = 0
+		case 127, 8: // backspace
+			if pos > 0 {
+				buf = append(buf[:pos - 1], buf[pos:]...)
+				pos--
+			}
+		case '\t':
+			candidates := e.complete(string(buf[:pos]))
+			word := []rune(lastWord(string(buf[:pos])))
+			prefix := []rune(commonPrefix(candidates))
+
+			switch {
+			case len(candidates) == 1:
+				insert(append(prefix[len(word):], ' '))
+			case len(prefix) > len(word):
+				insert(prefix[len(word):])
+			case len(candidates) > 1:
+				fmt.Printf("\r\n%s\r\n", strings.Join(candidates, "  "))
+			}
+		case 27: // escape sequences of the arrow, home, end and delete keys
+			pending = pending[:0]
+			for {
+				next, _, err := e.r.ReadRune()
+				if err != nil {
+					return "", err
+				}
+
+				pending = append(pending, next)
+				if next != '[' && next != 'O' && (next < '0' || next > '9') {
+					break
+				}
+			}
+
+			switch string(pending) {
+			case "[A", "OA":
+				if histPos > 0 {
+					histPos--
+					buf = []rune((*e.history)[histPos])
+					pos = len(buf)
+				}
+			case "[B", "OB":
+				if histPos < len(*e.history) {
+					histPos++
+					buf = nil
+					if histPos < len(*e.history) {
+						buf = []rune((*e.history)[histPos])
+					}
+					pos = len(buf)
+				}
+			case "[C", "OC":
+				if pos < len(buf) {
+					pos++
+				}
+			case "[D", "OD":
+				if pos > 0 {
+					pos--
+				}
+			case "[H", "OH", "[1~":
+				pos = 0
+			case "[F", "OF", "[4~":
+				pos = len(buf)
+			case "[3~":
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos + 1:]...)
+				}
+			}
+		default:
+			if r >= ' ' && r != utf8.RuneError {
+				insert([]rune{r})
+			}
+		}
+
+		redraw()
+	}
+}
+
+func newLineReader(history *[]string, complete completeFunc) lineReader {
+	r := bufio.NewReader(os.Stdin)
+
+	setRaw, ok := rawTerminal(os.Stdin)
+	if !ok {
+		// Still prompt on terminals that can not be put in raw mode, but not for piped scripts
+		info, err := os.Stdin.Stat()
+		return &plainReader{r: r, prompt: err == nil && info.Mode() & os.ModeCharDevice != 0}
+	}
+
+	return &editor{r: r, history: history, complete: complete, setRaw: setRaw}
+}
+
+func lastWord(line string) string {
+	idx := strings.LastIndexAny(line, " \t")
+	return line[idx + 1:]
+}
+
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix) - 1]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,48 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func ioctlTermios(fd uintptr, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// rawTerminal reports whether f is a terminal, and returns the function putting it in raw mode when it is
+func rawTerminal(f *os.File) (func() (func(), error), bool) {
+	fd := f.Fd()
+
+	var cooked syscall.Termios
+	if err := ioctlTermios(fd, syscall.TCGETS, &cooked); err != nil {
+		return nil, false
+	}
+
+	return func() (func(), error) {
+		if err := ioctlTermios(fd, syscall.TCGETS, &cooked); err != nil {
+			return nil, err
+		}
+
+		raw := cooked
+		raw.Iflag &^= syscall.ICRNL | syscall.IXON
+		raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		raw.Cc[syscall.VMIN] = 1
+		raw.Cc[syscall.VTIME] = 0
+
+		if err := ioctlTermios(fd, syscall.TCSETS, &raw); err != nil {
+			return nil, err
+		}
+
+		return func() {
+			ioctlTermios(fd, syscall.TCSETS, &cooked)
+		}, nil
+	}, true
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package main
+
+import (
+	"os"
+)
+
+// rawTerminal never edits lines outside linux, the shell falls back to reading plain lines
+func rawTerminal(f *os.File) (func() (func(), error), bool) {
+	return nil, false
+}
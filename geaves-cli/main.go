@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -13,12 +14,25 @@ import (
 
 func main() {
 	topFs := flag.NewFlagSet("top", flag.ExitOnError)
+
+	var outputString string
+	topFs.StringVar(&outputString, "output", string(outputText), "Output format of results, one of text, json, jsonl, csv or table")
+
+	var tenantString string
+	topFs.StringVar(&tenantString, "tenant", "", "Slug or id of the tenant to run the command in, defaults to the default tenant")
+
 	err := topFs.Parse(os.Args[1:])
 	if err != nil {
 		fmt.Printf("Error: %s\n", err)
 		usage(topFs, "<cmd>")
 	}
 
+	output, err := parseOutputFormat(outputString)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	if (topFs.NArg() == 0) {
 		usage(topFs, "<cmd>")
 		os.Exit(1)
@@ -27,48 +41,92 @@ func main() {
 	cmds := getTopCommands()
 	cmd, ok := cmds[topFs.Arg(0)]
 	if !ok {
-		fmt.Fprintf(os.Stderr, "%s: unknown command\n", topFs.Arg(0))
+		printError(output, fmt.Errorf("%s: unknown command", topFs.Arg(0)))
 		os.Exit(1)
 	}
 
 	uri, ok := os.LookupEnv("GEAVES_CONNECTION")
 	if !ok {
-		fmt.Fprintf(os.Stderr, "GEAVES_CONNECTION env does not exist\n")
+		printError(output, errors.New("GEAVES_CONNECTION env does not exist"))
 		os.Exit(1)
 	}
 
 	db, err := sql.Open("sqlite", uri)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to open sqlite connection: %s", err)
+		printError(output, fmt.Errorf("Failed to open sqlite connection: %w", err))
 		os.Exit(1)
 	}
 
-	tx, err := db.BeginTx(context.Background(), nil)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to start a transaction: %s", err)
-		os.Exit(1)
+	tenantID := geaves.DefaultTenantID
+	if tenantString != "" {
+		tenant, err := getTenantByIdOrSlug(tenantString, geaves.New(db))
+		if err != nil {
+			printError(output, fmt.Errorf("Failed to get tenant %s: %w", tenantString, err))
+			os.Exit(exitCode(err))
+		}
+
+		tenantID = tenant.ID
 	}
 
-	state := state{
+	s := state{
 		cmdName: topFs.Arg(0),
 		args: topFs.Args()[1:],
-		queries: geaves.New(db).WithTx(tx),
+		queries: geaves.New(db).ForTenant(tenantID),
+		db: db,
+		output: output,
+		tenant: tenantID,
 	}
 
-	err = cmd.callback(state)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-
-		err = tx.Rollback()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Also failed rollback: %s\n", err)
-		}
+	if cmd.ownTx {
+		err = cmd.callback(s)
+	} else {
+		err = geaves.RunInTx(context.Background(), db, &geaves.TxOptions{ReadOnly: cmd.readOnly, Tenant: tenantID}, func(q *geaves.Queries) error {
+			s.queries = q
+			return cmd.callback(s)
+		})
+	}
 
-		os.Exit(1)
+	if err != nil && !errors.Is(err, errRollback) {
+		printError(output, err)
+		os.Exit(exitCode(err))
 	}
+}
 
-	err = tx.Commit()
+// Exit codes of failed commands, 2 is left to the flag package for invalid flags
+const (
+	exitFailure = 1
+	exitUsage = 2
+	exitNotFound = 3
+	exitDuplicate = 4
+	exitInvalidType = 5
+	exitRequiredMissing = 6
+	exitForeignKey = 7
+	exitBusy = 8
+	exitMixedCurrencies = 9
+)
+
+// exitCode tells scripts what a command failed with by the geaves error it returned
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitUsage
+	case errors.Is(err, geaves.ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		return exitNotFound
+	case errors.Is(err, geaves.ErrDuplicate):
+		return exitDuplicate
+	case errors.Is(err, geaves.ErrRequiredMissing):
+		return exitRequiredMissing
+	case errors.Is(err, geaves.ErrInvalidType):
+		return exitInvalidType
+	case errors.Is(err, geaves.ErrForeignKey):
+		return exitForeignKey
+	case geaves.IsBusy(err):
+		return exitBusy
+	case errors.Is(err, geaves.ErrMixedCurrencies):
+		return exitMixedCurrencies
+	}
 
+	return exitFailure
 }
 
 func usage(fl *flag.FlagSet, cmd string) {
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/Asfolny/geaves"
+)
+
+func migrateCommand(s state) error {
+	migrateFs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+
+	var dryRun bool
+
+	migrateFs.BoolVar(&dryRun, "dry-run", false, "Rewrite and report, but roll everything back")
+	migrateFs.BoolVar(&dryRun, "d", false, "Rewrite and report, but roll everything back (shorthand)")
+
+	if err := migrateFs.Parse(s.args); err != nil {
+		return err
+	}
+
+	n, err := geaves.MigrateValues(context.Background(), s.queries)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		if err := writeRecord(s, migrateRecord{DryRun: dryRun, ValuesRewritten: n}); err != nil || !dryRun {
+			return err
+		}
+
+		return errRollback
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run, would have rewritten %v values\n", n)
+		return errRollback
+	}
+
+	fmt.Printf("Successfully rewrote %v values\n", n)
+	return nil
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Asfolny/geaves"
+)
+
+// outputFormat is how commands write their results, chosen with the global --output flag
+type outputFormat string
+
+const (
+	outputText outputFormat = "text"
+	outputJSON outputFormat = "json"
+	outputJSONL outputFormat = "jsonl"
+	outputCSV outputFormat = "csv"
+	outputTable outputFormat = "table"
+)
+
+func parseOutputFormat(format string) (outputFormat, error) {
+	switch outputFormat(format) {
+	case outputText, outputJSON, outputJSONL, outputCSV, outputTable:
+		return outputFormat(format), nil
+	}
+
+	return "", fmt.Errorf("Unknown output format %s, use one of text, json, jsonl, csv or table", format)
+}
+
+// isJSON reports whether errors are written as json too
+func (f outputFormat) isJSON() bool {
+	return f == outputJSON || f == outputJSONL
+}
+
+// outputRecord is a result of a command, written as json by its struct tags and as a flat row for csv and table
+type outputRecord interface {
+	header() []string
+	row() []string
+}
+
+type entityRecord struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Attributes []entityAttributeRecord `json:"attributes"`
+}
+
+type entityAttributeRecord struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type geaves.AttributeType `json:"type"`
+	Required bool `json:"required"`
+}
+
+func (r entityRecord) header() []string {
+	return []string{"id", "name", "slug", "attributes"}
+}
+
+func (r entityRecord) row() []string {
+	return []string{formatID(r.ID), r.Name, r.Slug, jsonCell(r.Attributes)}
+}
+
+type attributeRecord struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type geaves.AttributeType `json:"type"`
+	Entities []attributeEntityRecord `json:"entities"`
+}
+
+type attributeEntityRecord struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Required bool `json:"required"`
+}
+
+func (r attributeRecord) header() []string {
+	return []string{"id", "name", "slug", "type", "entities"}
+}
+
+func (r attributeRecord) row() []string {
+	return []string{formatID(r.ID), r.Name, r.Slug, string(r.Type), jsonCell(r.Entities)}
+}
+
+// itemRecord holds the values of an item by attribute slug, as written by geaves.Value.MarshalJSON
+type itemRecord struct {
+	ID int64 `json:"id"`
+	EntityID int64 `json:"entity_id"`
+	Entity string `json:"entity"`
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+func (r itemRecord) header() []string {
+	return []string{"id", "entity_id", "entity", "values"}
+}
+
+func (r itemRecord) row() []string {
+	return []string{formatID(r.ID), formatID(r.EntityID), r.Entity, jsonCell(r.Values)}
+}
+
+// itemValueRecord is the value of one attribute on an item, null once deleted
+type itemValueRecord struct {
+	ItemID int64 `json:"item_id"`
+	AttributeID int64 `json:"attribute_id"`
+	Attribute string `json:"attribute"`
+	Type geaves.AttributeType `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (r itemValueRecord) header() []string {
+	return []string{"item_id", "attribute_id", "attribute", "type", "value"}
+}
+
+func (r itemValueRecord) row() []string {
+	return []string{formatID(r.ItemID), formatID(r.AttributeID), r.Attribute, string(r.Type), string(r.Value)}
+}
+
+type tenantRecord struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+func (r tenantRecord) header() []string {
+	return []string{"id", "name", "slug"}
+}
+
+func (r tenantRecord) row() []string {
+	return []string{formatID(r.ID), r.Name, r.Slug}
+}
+
+// linkRecord is the link between an entity and an attribute after link, unlink, require or optional
+type linkRecord struct {
+	Entity string `json:"entity"`
+	Attribute string `json:"attribute"`
+	Linked bool `json:"linked"`
+	Required bool `json:"required"`
+}
+
+func (r linkRecord) header() []string {
+	return []string{"entity", "attribute", "linked", "required"}
+}
+
+func (r linkRecord) row() []string {
+	return []string{r.Entity, r.Attribute, strconv.FormatBool(r.Linked), strconv.FormatBool(r.Required)}
+}
+
+// statsRecord is one row of item stats, the values grouped by by attribute slug and the aggregate of the group
+type statsRecord struct {
+	Group map[string]json.RawMessage `json:"group"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (r statsRecord) header() []string {
+	return []string{"group", "value"}
+}
+
+func (r statsRecord) row() []string {
+	return []string{jsonCell(r.Group), string(r.Value)}
+}
+
+// csvImportRecord is what item import-csv created, the attributes it created and linked are slugs
+type csvImportRecord struct {
+	Entity string `json:"entity"`
+	ItemIDs []int64 `json:"item_ids"`
+	AttributesCreated []string `json:"attributes_created"`
+	AttributesLinked []string `json:"attributes_linked"`
+}
+
+func (r csvImportRecord) header() []string {
+	return []string{"entity", "item_ids", "attributes_created", "attributes_linked"}
+}
+
+func (r csvImportRecord) row() []string {
+	return []string{r.Entity, jsonCell(r.ItemIDs), jsonCell(r.AttributesCreated), jsonCell(r.AttributesLinked)}
+}
+
+// importRecord counts what import created and updated, rolled back again on a dry run
+type importRecord struct {
+	DryRun bool `json:"dry_run"`
+	EntitiesCreated int `json:"entities_created"`
+	AttributesCreated int `json:"attributes_created"`
+	LinksCreated int `json:"links_created"`
+	LinksUpdated int `json:"links_updated"`
+	ItemsCreated int `json:"items_created"`
+	ValuesSet int `json:"values_set"`
+}
+
+func (r importRecord) header() []string {
+	return []string{"dry_run", "entities_created", "attributes_created", "links_created", "links_updated", "items_created", "values_set"}
+}
+
+func (r importRecord) row() []string {
+	return []string{
+		strconv.FormatBool(r.DryRun),
+		strconv.Itoa(r.EntitiesCreated),
+		strconv.Itoa(r.AttributesCreated),
+		strconv.Itoa(r.LinksCreated),
+		strconv.Itoa(r.LinksUpdated),
+		strconv.Itoa(r.ItemsCreated),
+		strconv.Itoa(r.ValuesSet),
+	}
+}
+
+// schemaChangeRecord is one change of schema plan and schema apply
+type schemaChangeRecord struct {
+	Kind geaves.SchemaChangeKind `json:"kind"`
+	Entity string `json:"entity"`
+	Attribute string `json:"attribute"`
+	From string `json:"from"`
+	To string `json:"to"`
+	Type geaves.AttributeType `json:"type"`
+	Destructive bool `json:"destructive"`
+}
+
+func (r schemaChangeRecord) header() []string {
+	return []string{"kind", "entity", "attribute", "from", "to", "type", "destructive"}
+}
+
+func (r schemaChangeRecord) row() []string {
+	return []string{string(r.Kind), r.Entity, r.Attribute, r.From, r.To, string(r.Type), strconv.FormatBool(r.Destructive)}
+}
+
+// jsonSchemaImportRecord is what schema import created and changed, the attributes are slugs
+type jsonSchemaImportRecord struct {
+	Entity string `json:"entity"`
+	EntityCreated bool `json:"entity_created"`
+	AttributesCreated []string `json:"attributes_created"`
+	LinksCreated []string `json:"links_created"`
+	LinksUpdated []string `json:"links_updated"`
+}
+
+func (r jsonSchemaImportRecord) header() []string {
+	return []string{"entity", "entity_created", "attributes_created", "links_created", "links_updated"}
+}
+
+func (r jsonSchemaImportRecord) row() []string {
+	return []string{r.Entity, strconv.FormatBool(r.EntityCreated), jsonCell(r.AttributesCreated), jsonCell(r.LinksCreated), jsonCell(r.LinksUpdated)}
+}
+
+// migrateRecord counts the values migrate rewrote, rolled back again on a dry run
+type migrateRecord struct {
+	DryRun bool `json:"dry_run"`
+	ValuesRewritten int `json:"values_rewritten"`
+}
+
+func (r migrateRecord) header() []string {
+	return []string{"dry_run", "values_rewritten"}
+}
+
+func (r migrateRecord) row() []string {
+	return []string{strconv.FormatBool(r.DryRun), strconv.Itoa(r.ValuesRewritten)}
+}
+
+// orEmpty keeps a list without elements from being written as null
+func orEmpty[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+
+	return list
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// jsonCell flattens nested fields into one csv or table cell
+func jsonCell(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// writeRecords writes the results of a command, json as one array and the other formats a record per line or row
+func writeRecords[T outputRecord](s state, records []T) error {
+	if s.output == outputJSON {
+		if records == nil {
+			records = []T{}
+		}
+
+		return writeJSON(os.Stdout, records)
+	}
+
+	return writeRows(s, records)
+}
+
+// writeRecord writes the single result of a command, json as one object
+func writeRecord[T outputRecord](s state, record T) error {
+	if s.output == outputJSON {
+		return writeJSON(os.Stdout, record)
+	}
+
+	return writeRows(s, []T{record})
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeRows[T outputRecord](s state, records []T) error {
+	var zero T
+	header := zero.header()
+
+	switch s.output {
+	case outputJSONL:
+		enc := json.NewEncoder(os.Stdout)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case outputCSV:
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(header); err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if err := w.Write(record.row()); err != nil {
+				return err
+			}
+		}
+
+		w.Flush()
+		return w.Error()
+	case outputTable:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+		for _, record := range records {
+			fmt.Fprintln(w, strings.Join(record.row(), "\t"))
+		}
+
+		return w.Flush()
+	}
+
+	return fmt.Errorf("Output format %s can not write records", s.output)
+}
+
+// printError writes err to stderr, as {"error": "..."} when a json format is chosen
+func printError(format outputFormat, err error) {
+	if format.isJSON() {
+		data, jsonErr := json.Marshal(struct{
+			Error string `json:"error"`
+		}{err.Error()})
+		if jsonErr == nil {
+			fmt.Fprintln(os.Stderr, string(data))
+			return
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, err)
+}
+
+func toEntityRecord(entity geaves.Entity, queries *geaves.Queries) (entityRecord, error) {
+	res := entityRecord{ID: entity.ID, Name: entity.Name, Slug: entity.Slug, Attributes: []entityAttributeRecord{}}
+
+	attributes, err := entity.GetAttributes(context.Background(), queries)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return res, fmt.Errorf("Failed to get attributes: %w", err)
+	}
+
+	for _, attribute := range attributes {
+		res.Attributes = append(res.Attributes, entityAttributeRecord{
+			ID: attribute.ID,
+			Name: attribute.Name,
+			Slug: attribute.Slug,
+			Type: attribute.Type,
+			Required: attribute.Required,
+		})
+	}
+
+	return res, nil
+}
+
+func toAttributeRecord(attribute geaves.Attribute, queries *geaves.Queries) (attributeRecord, error) {
+	res := attributeRecord{ID: attribute.ID, Name: attribute.Name, Slug: attribute.Slug, Type: attribute.Type, Entities: []attributeEntityRecord{}}
+
+	entities, err := attribute.GetEntities(context.Background(), queries)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return res, fmt.Errorf("Failed to get entities that use this attribute: %w", err)
+	}
+
+	for _, entity := range entities {
+		res.Entities = append(res.Entities, attributeEntityRecord{
+			ID: entity.ID,
+			Name: entity.Name,
+			Slug: entity.Slug,
+			Required: entity.Required,
+		})
+	}
+
+	return res, nil
+}
+
+func toItemRecord(item geaves.Item, queries *geaves.Queries) (itemRecord, error) {
+	res := itemRecord{ID: item.ID, EntityID: item.EntityID, Values: map[string]json.RawMessage{}}
+
+	entity, err := queries.GetEntity(context.Background(), geaves.GetEntityParam{Field: geaves.ByID, Value: item.EntityID})
+	if err != nil {
+		return res, fmt.Errorf("Failed to get entity for item: %w", err)
+	}
+
+	res.Entity = entity.Slug
+
+	values, err := queries.ListItemValues(context.Background(), item.ID)
+	if err != nil {
+		return res, fmt.Errorf("Failed to get item values: %w", err)
+	}
+
+	for _, value := range values {
+		data, err := json.Marshal(value.Value)
+		if err != nil {
+			return res, fmt.Errorf("Failed to write value of %s: %w", value.Slug, err)
+		}
+
+		res.Values[value.Slug] = data
+	}
+
+	return res, nil
+}
+
+// toItemValueRecord reads the current value of attribute on an item, null when it has none
+func toItemValueRecord(itemId int64, attribute geaves.Attribute, queries *geaves.Queries) (itemValueRecord, error) {
+	res := itemValueRecord{ItemID: itemId, AttributeID: attribute.ID, Attribute: attribute.Slug, Type: attribute.Type, Value: json.RawMessage("null")}
+
+	values, err := queries.ListItemValues(context.Background(), itemId)
+	if err != nil {
+		return res, fmt.Errorf("Failed to get item values: %w", err)
+	}
+
+	for _, value := range values {
+		if value.AttributeID != attribute.ID {
+			continue
+		}
+
+		data, err := json.Marshal(value.Value)
+		if err != nil {
+			return res, fmt.Errorf("Failed to write value of %s: %w", value.Slug, err)
+		}
+
+		res.Value = data
+	}
+
+	return res, nil
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Asfolny/geaves"
+)
+
+func schemaCommand(s state) error {
+	if len(s.args) < 1 {
+		// TODO print subcommand usage instead
+		return fmt.Errorf("%s requires 1 argument, the subcommand", s.cmdName)
+	}
+
+	cmds := getSchemaCommands()
+	cmd, ok := cmds[s.args[0]]
+	if !ok {
+		return fmt.Errorf("%s: schema command not found\n", s.args[0])
+	}
+
+	return  cmd.callback(state{s.args[0], s.args[1:], s.queries, s.db, s.output, s.tenant})
+}
+
+func getSchemaCommands() map[string]command {
+	return map[string]command{
+		"plan": {
+			name: "schema plan <file>",
+			description: "Show the changes needed to make the database match a schema file",
+			callback: planSchemaCommand,
+		},
+		"apply": {
+			name: "schema apply <flags> <file>",
+			description: "Change the database to match a schema file",
+			callback: applySchemaCommand,
+		},
+		"dump": {
+			name: "schema dump [file]",
+			description: "Write the schema of the database as a schema file",
+			callback: dumpSchemaCommand,
+		},
+		"import-jsonschema": {
+			name: "schema import-jsonschema <flags> <file>",
+			description: "Create an entity, its attributes and links from a JSON Schema object",
+			callback: importJSONSchemaCommand,
+		},
+		"help": {
+			name: "schema help",
+			description: "Prints this message",
+			callback: helpSchemaCommand,
+		},
+	}
+}
+
+func readSchemaFile(path string) (geaves.Schema, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return geaves.Schema{}, fmt.Errorf("Failed to open schema file: %w", err)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	return geaves.ReadSchema(r)
+}
+
+func planSchemaCommand(s state) error {
+	if len(s.args) < 1 {
+		return fmt.Errorf("%s requires 1 argument, the schema file", s.cmdName)
+	}
+
+	schema, err := readSchemaFile(s.args[0])
+	if err != nil {
+		return err
+	}
+
+	plan, err := geaves.PlanSchema(context.Background(), s.queries, schema)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecords(s, toSchemaChangeRecords(plan))
+	}
+
+	if len(plan.Changes) == 0 {
+		fmt.Println("Database matches the schema, nothing to do")
+		return nil
+	}
+
+	fmt.Print(plan.String())
+
+	if len(plan.Destructive()) > 0 {
+		fmt.Println("Plan has destructive changes, apply with --allow-destructive to apply it")
+	}
+
+	return nil
+}
+
+func applySchemaCommand(s state) error {
+	applyFs := flag.NewFlagSet("schema", flag.ContinueOnError)
+
+	var allowDestructive bool
+
+	applyFs.BoolVar(&allowDestructive, "allow-destructive", false, "Apply changes that delete items or values")
+	applyFs.BoolVar(&allowDestructive, "D", false, "Apply changes that delete items or values (shorthand)")
+
+	args, err := parseInterspersed(applyFs, s.args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("%s requires 1 argument, the schema file", s.cmdName)
+	}
+
+	schema, err := readSchemaFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	plan, err := geaves.ApplySchema(context.Background(), s.queries, schema, geaves.ApplySchemaOptions{AllowDestructive: allowDestructive})
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecords(s, toSchemaChangeRecords(plan))
+	}
+
+	if len(plan.Changes) == 0 {
+		fmt.Println("Database matches the schema, nothing to do")
+		return nil
+	}
+
+	fmt.Print(plan.String())
+	fmt.Printf("Successfully applied %v changes\n", len(plan.Changes))
+	return nil
+}
+
+// toSchemaChangeRecords lists the changes of plan as records, in the order they are applied
+func toSchemaChangeRecords(plan geaves.SchemaPlan) []schemaChangeRecord {
+	records := make([]schemaChangeRecord, len(plan.Changes))
+	for idx, change := range plan.Changes {
+		records[idx] = schemaChangeRecord{
+			Kind: change.Kind,
+			Entity: change.Entity,
+			Attribute: change.Attribute,
+			From: change.From,
+			To: change.To,
+			Type: change.Type,
+			Destructive: change.Destructive,
+		}
+	}
+
+	return records
+}
+
+func dumpSchemaCommand(s state) error {
+	schema, err := geaves.DumpSchema(context.Background(), s.queries)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if len(s.args) >= 1 && s.args[0] != "-" {
+		f, err := os.Create(s.args[0])
+		if err != nil {
+			return fmt.Errorf("Failed to create schema file: %w", err)
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	return geaves.WriteSchema(w, schema)
+}
+
+func importJSONSchemaCommand(s state) error {
+	importFs := flag.NewFlagSet("schema", flag.ContinueOnError)
+
+	var opts geaves.JSONSchemaImportOptions
+
+	importFs.StringVar(&opts.Name, "name", "", "Name of the entity, instead of the title of the schema")
+	importFs.StringVar(&opts.Name, "n", "", "Name of the entity, instead of the title of the schema (shorthand)")
+
+	importFs.StringVar(&opts.Slug, "slug", "", "Slug of the entity, instead of the one taken from $id")
+	importFs.StringVar(&opts.Slug, "s", "", "Slug of the entity, instead of the one taken from $id (shorthand)")
+
+	args, err := parseInterspersed(importFs, s.args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("%s requires 1 argument, the json schema file", s.cmdName)
+	}
+
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("Failed to open json schema file: %w", err)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	schema, err := geaves.ReadJSONSchema(r)
+	if err != nil {
+		return err
+	}
+
+	res, err := geaves.ImportJSONSchema(context.Background(), s.queries, schema, opts)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, jsonSchemaImportRecord{
+			Entity: res.Entity.Slug,
+			EntityCreated: res.EntityCreated,
+			AttributesCreated: orEmpty(res.AttributesCreated),
+			LinksCreated: orEmpty(res.LinksCreated),
+			LinksUpdated: orEmpty(res.LinksUpdated),
+		})
+	}
+
+	if res.EntityCreated {
+		fmt.Printf("Created entity %s (%s)\n", res.Entity.Name, res.Entity.Slug)
+	} else {
+		fmt.Printf("Using existing entity %s (%s)\n", res.Entity.Name, res.Entity.Slug)
+	}
+
+	for _, slug := range res.AttributesCreated {
+		fmt.Printf("Created attribute %s\n", slug)
+	}
+
+	for _, slug := range res.LinksCreated {
+		fmt.Printf("Linked %s\n", slug)
+	}
+
+	for _, slug := range res.LinksUpdated {
+		fmt.Printf("Changed whether %s is required\n", slug)
+	}
+
+	return nil
+}
+
+func helpSchemaCommand(s state) (err error) {
+	if len(s.args) > 0 {
+		switch (s.args[0]) {
+		case "plan":
+			fmt.Print(`
+geaves-cli schema plan <file>
+
+Print the changes needed to make the database match a schema file, use - as file to read stdin
+Changes marked destructive delete items or values, and are only applied with schema apply --allow-destructive
+`)
+			return
+		case "apply":
+			fmt.Print(`
+geaves-cli schema apply <flags> <file>
+
+Change the database to match a schema file, use - as file to read stdin
+All changes are applied in one transaction, if any fails nothing is changed
+
+Available flags
+  -D | --allow-destructive  - Apply destructive changes (dropped entities, attributes or links and type changes),
+                              without this a plan with destructive changes is not applied at all
+`)
+			return
+		case "dump":
+			fmt.Print(`
+geaves-cli schema dump [file]
+
+Write the schema of the database as a schema file, to file or stdout when no file (or -) is given
+Attributes, entities and links are sorted by slug, so dumps of the same schema are identical
+Applying a dump to the database it came from changes nothing
+`)
+			return
+		case "import-jsonschema":
+			fmt.Print(`
+geaves-cli schema import-jsonschema <flags> <file>
+
+Create an entity from a JSON Schema object, use - as file to read stdin
+Every property becomes an attribute linked to the entity, and is required when listed in required
+Existing entities and attributes are reused by slug, an existing attribute must have the type of its property
+
+The slug of the entity is taken from $id (product.schema.json becomes product), and the name from title
+Attribute types come from type, format and the minimum and maximum of integers, as written by generate jsonschema
+
+Available flags
+  -n | --name [name]  - Name of the entity, instead of the title of the schema
+  -s | --slug [slug]  - Slug of the entity, instead of the one taken from $id
+`)
+			return
+		default:
+			fmt.Println("Unknown subcommand, usage:")
+		}
+	}
+
+	fmt.Print(`
+geaves-cli schema [subcommand]
+
+A schema file is json declaring every attribute and entity, and the attributes linked to each entity
+Attributes and entities are matched to the database by slug, anything in the database not in the file is dropped
+
+{
+  "version": 1,
+  "attributes": [
+    {"name": "Price", "slug": "price", "type": "float64"}
+  ],
+  "entities": [
+    {"name": "Product", "slug": "product", "attributes": [
+      {"slug": "price", "required": true}
+    ]}
+  ]
+}
+
+Available subcommands
+  plan <file>          - print the changes needed to make the database match a schema file
+  apply <flags> <file> - make the database match a schema file
+  dump [file]          - write the schema of the database as a schema file
+  import-jsonschema <flags> <file>
+                       - create an entity from a JSON Schema object
+  help [subcommand]    - Print this message or help message of a subcommand
+`)
+	return
+}
@@ -69,7 +69,7 @@ export GEAVES_CONNECTION="$table?_pragma=foreign_keys(1)"
 ./geaves item add 1 r "b"
 ./geaves item add 1 f32 "1.2"
 ./geaves item add 1 f64 "1.3"
-./geaves item add 1 blob "asjdkf"
+./geaves item add 1 blob "YXNqZGtm"
 ./geaves item add 1 date "2025-01-01"
 ./geaves item add 1 time "01:02:03"
 ./geaves item add 1 datetime "2025-01-01 11:02:03"
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/Asfolny/geaves/server"
+)
+
+func serveCommand(s state) error {
+	serveFs := flag.NewFlagSet("serve", flag.ContinueOnError)
+
+	var addr string
+
+	serveFs.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	serveFs.StringVar(&addr, "a", ":8080", "Address to listen on (shorthand)")
+
+	if err := serveFs.Parse(s.args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	srv := &http.Server{Addr: addr, Handler: server.NewTenantHandler(s.db, s.tenant)}
+
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	fmt.Printf("Serving on %s\n", addr)
+	err := srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
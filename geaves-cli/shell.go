@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Asfolny/geaves"
+)
+
+// shell runs commands in their own transaction, or in a savepoint of the transaction started with begin,
+// all on the one connection it takes when it starts
+type shell struct {
+	db *sql.DB
+	conn *sql.Conn
+	output outputFormat
+	// tenant is the id of the tenant every command is scoped to
+	tenant int64
+	// inTx is whether a transaction started with begin is open on conn
+	inTx bool
+	history []string
+	historyFile string
+}
+
+// Commands of the shell itself, on top of the top level commands
+var shellBuiltins = []string{"begin", "commit", "rollback", "history", "exit", "quit"}
+
+// Commands with sub commands, completed before slugs
+var shellSubCommands = map[string]func() map[string]command{
+	"entity": getEntityCommands,
+	"attribute": getAttributeCommands,
+	"item": getItemCommands,
+	"schema": getSchemaCommands,
+	"tenant": getTenantCommands,
+}
+
+var shellGenerateTypes = []string{"setup-sql", "reset-sql", "tenants-sql", "goose", "views", "go", "openapi", "jsonschema"}
+
+func shellCommand(s state) error {
+	conn, err := s.db.Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("Failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	sh := &shell{db: s.db, conn: conn, output: s.output, tenant: s.tenant}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		sh.historyFile = filepath.Join(home, ".geaves_history")
+		sh.loadHistory()
+	}
+
+	reader := newLineReader(&sh.history, sh.complete)
+	for {
+		line, err := reader.ReadLine(sh.prompt())
+		if errors.Is(err, errInterrupt) {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		args, err := splitShellLine(line)
+		if err != nil {
+			printError(sh.output, err)
+			continue
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		sh.addHistory(line)
+
+		if args[0] == "exit" || args[0] == "quit" {
+			break
+		}
+
+		if err := sh.run(args); err != nil {
+			printError(sh.output, err)
+		}
+	}
+
+	if sh.inTx {
+		fmt.Fprintln(os.Stderr, "Rolling back the open transaction")
+		if err := sh.end("ROLLBACK"); err != nil {
+			return fmt.Errorf("Failed rollback: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (sh *shell) prompt() string {
+	if sh.inTx {
+		return "geaves (tx)> "
+	}
+
+	return "geaves> "
+}
+
+func (sh *shell) run(args []string) error {
+	switch args[0] {
+	case "begin":
+		if sh.inTx {
+			return errors.New("A transaction is already open, commit or rollback first")
+		}
+
+		// Immediate takes the write lock now, instead of failing on a busy database half way through
+		if _, err := sh.conn.ExecContext(context.Background(), "BEGIN IMMEDIATE"); err != nil {
+			return fmt.Errorf("Failed to start a transaction: %w", err)
+		}
+
+		sh.inTx = true
+		return nil
+	case "commit", "rollback":
+		if !sh.inTx {
+			return fmt.Errorf("No transaction is open to %s", args[0])
+		}
+
+		return sh.end(strings.ToUpper(args[0]))
+	case "history":
+		for idx, line := range sh.history {
+			fmt.Printf("%5d  %s\n", idx + 1, line)
+		}
+		return nil
+	case "shell":
+		return errors.New("Already in a shell")
+	case "help":
+		if len(args) == 1 {
+			err := helpCommand(state{"help", nil, nil, sh.db, sh.output, sh.tenant})
+			fmt.Print(`
+Shell commands
+  begin                         - Start a transaction, commands run in it until commit or rollback
+  commit                        - Commit the transaction
+  rollback                      - Roll the transaction back
+  history                       - Print the command history
+  exit | quit                   - Leave the shell, an open transaction is rolled back
+`)
+			return err
+		}
+	}
+
+	cmd, ok := getTopCommands()[args[0]]
+	if !ok {
+		return fmt.Errorf("%s: unknown command", args[0])
+	}
+
+	run := func(q *geaves.Queries) error {
+		return cmd.callback(state{args[0], args[1:], q, sh.db, sh.output, sh.tenant})
+	}
+
+	var err error
+	switch {
+	case cmd.ownTx:
+		return fmt.Errorf("%s can not run in the shell, it begins its own transactions", args[0])
+	case sh.inTx:
+		// A failing command only undoes its own changes, the transaction stays open
+		err = geaves.New(sh.conn).ForTenant(sh.tenant).RunInTx(context.Background(), run)
+	default:
+		err = geaves.RunInConn(context.Background(), sh.conn, &geaves.TxOptions{ReadOnly: cmd.readOnly, Tenant: sh.tenant}, run)
+	}
+
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+
+	return err
+}
+
+// end commits or rolls back the transaction started with begin
+func (sh *shell) end(stmt string) error {
+	_, err := sh.conn.ExecContext(context.Background(), stmt)
+	if err != nil && stmt == "COMMIT" {
+		// The transaction may be left open by a failed commit, the next command would run in it
+		sh.conn.ExecContext(context.Background(), "ROLLBACK")
+	}
+
+	sh.inTx = false
+	return err
+}
+
+func (sh *shell) loadHistory() {
+	f, err := os.Open(sh.historyFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sh.history = append(sh.history, scanner.Text())
+	}
+}
+
+func (sh *shell) addHistory(line string) {
+	if len(sh.history) > 0 && sh.history[len(sh.history) - 1] == line {
+		return
+	}
+
+	sh.history = append(sh.history, line)
+
+	if sh.historyFile == "" {
+		return
+	}
+
+	f, err := os.OpenFile(sh.historyFile, os.O_APPEND | os.O_CREATE | os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, line)
+}
+
+// complete returns the candidates for the last word of line, command names first and then the
+// slugs of entities and attributes
+func (sh *shell) complete(line string) []string {
+	words := strings.Fields(line)
+	word := lastWord(line)
+	if word == "" {
+		words = append(words, "")
+	}
+
+	var candidates []string
+	switch {
+	case len(words) <= 1:
+		for name := range getTopCommands() {
+			candidates = append(candidates, name)
+		}
+		candidates = append(candidates, shellBuiltins...)
+	case len(words) == 2 && words[0] == "generate":
+		candidates = shellGenerateTypes
+	case len(words) == 2 && shellSubCommands[words[0]] != nil:
+		for name := range shellSubCommands[words[0]]() {
+			candidates = append(candidates, name)
+		}
+	case len(words) == 2 && words[0] == "help":
+		for name := range getTopCommands() {
+			candidates = append(candidates, name)
+		}
+	default:
+		candidates = sh.slugs()
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) && !slices.Contains(matches, candidate) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	slices.Sort(matches)
+	return matches
+}
+
+// slugs lists every entity and attribute slug, read in the open transaction when there is one
+func (sh *shell) slugs() []string {
+	q := geaves.New(sh.conn).ForTenant(sh.tenant)
+
+	var slugs []string
+
+	entities, err := q.ListEntities(context.Background(), false)
+	if err == nil {
+		for _, entity := range entities {
+			slugs = append(slugs, entity.Slug)
+		}
+	}
+
+	attributes, err := q.ListAttributes(context.Background(), false)
+	if err == nil {
+		for _, attribute := range attributes {
+			slugs = append(slugs, attribute.Slug)
+		}
+	}
+
+	return slugs
+}
+
+// splitShellLine splits a line into arguments on spaces, keeping quoted text together as the shell would
+func splitShellLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inWord := false
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated %c quote", quote)
+	}
+
+	if inWord {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
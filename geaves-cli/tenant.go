@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Asfolny/geaves"
+)
+
+func tenantCommand(s state) error {
+	if len(s.args) < 1 {
+		return fmt.Errorf("%s requires 1 argument, the subcommand", s.cmdName)
+	}
+
+	cmds := getTenantCommands()
+	cmd, ok := cmds[s.args[0]]
+	if !ok {
+		return fmt.Errorf("%s: tenant command not found\n", s.args[0])
+	}
+
+	return cmd.callback(state{s.args[0], s.args[1:], s.queries, s.db, s.output, s.tenant})
+}
+
+func getTenantCommands() map[string]command {
+	return map[string]command{
+		"create": {
+			name: "tenant create <flags>",
+			description: "Create a new tenant",
+			callback: createTenantCommand,
+		},
+		"update": {
+			name: "tenant update <flags> <slug|id>",
+			description: "Update a tenant by id or slug",
+			callback: updateTenantCommand,
+		},
+		"list": {
+			name: "tenant list",
+			description: "List all tenants",
+			callback: listTenantsCommand,
+		},
+		"info": {
+			name: "tenant info <slug|id>",
+			description: "Get tenant information by slug or by id",
+			callback: infoTenantCommand,
+		},
+		"delete": {
+			name: "tenant delete <slug|id>",
+			description: "Delete a tenant and everything in it by slug or by id",
+			callback: deleteTenantCommand,
+		},
+		"help": {
+			name: "tenant help",
+			description: "Prints this message",
+			callback: helpTenantCommand,
+		},
+	}
+}
+
+func createTenantCommand(s state) error {
+	createFs := flag.NewFlagSet("tenant", flag.ContinueOnError)
+
+	var name string
+	var slug string
+
+	createFs.StringVar(&name, "name", "", "Name of new tenant")
+	createFs.StringVar(&name, "n", "", "Name of new tenant (shorthand)")
+
+	createFs.StringVar(&slug, "slug", "", "Slug for new tenant")
+	createFs.StringVar(&slug, "s", "", "Slug for new tenant (shorthand)")
+
+	if err := createFs.Parse(s.args); err != nil {
+		return err
+	}
+
+	if name == "" || slug == "" {
+		return fmt.Errorf("Both name and slug must be provided, but one was empty")
+	}
+
+	tenant, err := s.queries.CreateTenant(context.Background(), geaves.CreateTenantParam{Name: name, Slug: slug})
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, toTenantRecord(tenant))
+	}
+
+	fmt.Printf("Successfully created %s (%s)\n", tenant.Name, tenant.Slug)
+	return nil
+}
+
+func listTenantsCommand(s state) error {
+	tenants, err := s.queries.ListTenants(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		records := make([]tenantRecord, len(tenants))
+		for idx, tenant := range tenants {
+			records[idx] = toTenantRecord(tenant)
+		}
+
+		return writeRecords(s, records)
+	}
+
+	var sb strings.Builder
+	for _, tenant := range tenants {
+		sb.WriteString(tenantToString(tenant))
+	}
+
+	fmt.Print(sb.String())
+	return nil
+}
+
+func infoTenantCommand(s state) error {
+	if len(s.args) < 1 {
+		return fmt.Errorf("%s requires an argument, the slug or id of the tenant to look up", s.cmdName)
+	}
+
+	tenant, err := getTenantByIdOrSlug(s.args[0], s.queries)
+	if err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, toTenantRecord(tenant))
+	}
+
+	fmt.Print(tenantToString(tenant))
+	return nil
+}
+
+func updateTenantCommand(s state) error {
+	updateFs := flag.NewFlagSet("tenant", flag.ContinueOnError)
+
+	var name string
+	var slug string
+
+	updateFs.StringVar(&name, "name", "", "Update the name of a tenant")
+	updateFs.StringVar(&name, "n", "", "Update the name of a tenant (shorthand)")
+
+	updateFs.StringVar(&slug, "slug", "", "Update the slug of a tenant")
+	updateFs.StringVar(&slug, "s", "", "Update the slug of a tenant (shorthand)")
+
+	if err := updateFs.Parse(s.args); err != nil {
+		return err
+	}
+
+	if name == "" && slug == "" {
+		return fmt.Errorf("No updating flags were given, nothing to do")
+	}
+
+	if updateFs.NArg() < 1 {
+		return fmt.Errorf("%s requires an argument, the slug or id of the tenant to look up", s.cmdName)
+	}
+
+	tenant, err := getTenantByIdOrSlug(updateFs.Arg(0), s.queries)
+	if err != nil {
+		return err
+	}
+
+	updated := tenant
+	if name != "" && name != tenant.Name {
+		if err := s.queries.UpdateTenantName(context.Background(), name, tenant.ID); err != nil {
+			return err
+		}
+		updated.Name = name
+	}
+
+	if slug != "" && slug != tenant.Slug {
+		if err := s.queries.UpdateTenantSlug(context.Background(), slug, tenant.ID); err != nil {
+			return err
+		}
+		updated.Slug = slug
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, toTenantRecord(updated))
+	}
+
+	if updated == tenant {
+		fmt.Println("Tenant already has these fields, nothing to do")
+		return nil
+	}
+
+	fmt.Printf("Successfully updated %v %s (%s)\n", updated.ID, updated.Name, updated.Slug)
+	return nil
+}
+
+func deleteTenantCommand(s state) error {
+	if len(s.args) < 1 {
+		return fmt.Errorf("%s requires 1 argument, either the id or the slug of the tenant", s.cmdName)
+	}
+
+	tenant, err := getTenantByIdOrSlug(s.args[0], s.queries)
+	if err != nil {
+		return err
+	}
+
+	if err := s.queries.DeleteTenant(context.Background(), tenant.ID); err != nil {
+		return err
+	}
+
+	if s.output != outputText {
+		return writeRecord(s, toTenantRecord(tenant))
+	}
+
+	fmt.Printf("Successfully deleted %s\n", tenant.Name)
+	return nil
+}
+
+func getTenantByIdOrSlug(search string, queries *geaves.Queries) (geaves.Tenant, error) {
+	id, err := strconv.ParseInt(search, 10, 64)
+	if err == nil {
+		return queries.GetTenant(context.Background(), geaves.GetTenantParam{Field: geaves.ByID, Value: id})
+	}
+
+	return queries.GetTenant(context.Background(), geaves.GetTenantParam{Field: geaves.BySlug, Value: search})
+}
+
+func toTenantRecord(tenant geaves.Tenant) tenantRecord {
+	return tenantRecord{ID: tenant.ID, Name: tenant.Name, Slug: tenant.Slug}
+}
+
+func tenantToString(tenant geaves.Tenant) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("%s\n", strings.Repeat("-", 20)))
+	sb.WriteString(fmt.Sprintf("| %v.%s (%s)\n", tenant.ID, tenant.Name, tenant.Slug))
+	sb.WriteString(fmt.Sprintf("%s\n", strings.Repeat("-", 20)))
+
+	return sb.String()
+}
+
+func helpTenantCommand(s state) (err error) {
+	if len(s.args) > 0 {
+		switch (s.args[0]) {
+		case "create":
+			fmt.Print(`
+geaves-cli tenant create <flags>
+
+Create a new tenant, which starts without entities, attributes or items
+
+Required flags
+  -n | --name  - name of the new tenant
+  -s | --slug  - slug of the new tenant
+`)
+			return
+		case "update":
+			fmt.Print(`
+geaves-cli tenant update <flags> <slug|id>
+NOTE flags must be before arguments
+
+Update an existing tenant by slug or id
+
+Available flags
+  -n | --name  - new name of the tenant
+  -s | --slug  - new slug of the tenant
+
+Either name or slug is required
+`)
+			return
+		case "list":
+			fmt.Print(`
+geaves-cli tenant list
+
+List all tenants, the default tenant has id 0
+`)
+			return
+		case "info":
+			fmt.Print(`
+geaves-cli tenant info <slug|id>
+
+Print details of a single tenant
+`)
+			return
+		case "delete":
+			fmt.Print(`
+geaves-cli tenant delete <slug|id>
+
+Delete a tenant by its id or slug, along with its entities, attributes, items and entity views
+The default tenant can not be deleted
+`)
+			return
+		default:
+			fmt.Println("Unknown subcommand, usage:")
+		}
+	}
+
+	fmt.Print(`
+geaves-cli tenant [subcommand]
+
+Tenants hold entities, attributes and items of their own, slugs only need to be unique within a tenant
+Other commands run in the tenant given with --tenant, or in the default tenant without it
+
+Available subcommands
+  create <flags>           - create a new tenant with data provided in flags
+  update <flags> <slug|id> - update using data provided in flags by tenant id or tenant slug
+  list                     - list all tenants
+  info <slug|id>           - details of a single tenant by id or slug
+  delete <slug|id>         - delete a tenant and everything in it by slug or id
+  help [subcommand]        - Print this message or help message of a subcommand
+`)
+	return
+}
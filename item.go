@@ -2,16 +2,30 @@ package geaves
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
 	"time"
 )
 
+// Item is an item of an entity, Load reads its values and Set and Unset change them in memory until Save
 type Item struct {
 	ID int64
 	EntityID int64
-	attributes []ItemAttribute[any]
+	values []ItemValue
+	changes []Change
 	entity *Entity
 	loadedEntity bool
-	loadedAttributes bool
+	loadedValues bool
+}
+
+// Change is a value set or unset on an Item that is not saved yet
+type Change struct {
+	Slug string
+	// Data is the new value in the Go type of the attribute (see Value), nil when it is unset
+	Data any
 }
 
 type ItemAttribute[T any] struct {
@@ -39,6 +53,8 @@ func (i *Item) ChangeEntityID(ctx context.Context, entityId int64, q *Queries) e
 	// TODO get "attributes" out of ctx and "add" them to the item
 
 	i.EntityID = entityId
+	i.values, i.changes = nil, nil
+	i.entity, i.loadedEntity, i.loadedValues = nil, false, false
 	return nil
 }
 
@@ -51,57 +67,308 @@ func (i *Item) Delete(ctx context.Context, q *Queries) error {
 	return q.DeleteItem(ctx, i.ID)
 }
 
+// Load reads the entity of i with its attributes, and the values of i when it is saved,
+// changes that are not saved yet are kept
+func (i *Item) Load(ctx context.Context, q *Queries) error {
+	entity, err := q.GetEntity(ctx, GetEntityParam{WithAttributes: true, Field: ByID, Value: i.EntityID})
+	if err != nil {
+		return fmt.Errorf("Failed to get entity of item %v: %w", i.ID, err)
+	}
+
+	i.entity, i.loadedEntity = &entity, true
+
+	if i.ID == 0 {
+		i.values, i.loadedValues = nil, true
+		return nil
+	}
+
+	i.values, err = q.ListItemValues(ctx, i.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to get values of item %v: %w", i.ID, err)
+	}
+
+	i.loadedValues = true
+	return nil
+}
+
+// Get returns the value i has for the attribute with slug, with changes that are not saved yet,
+// ok is false when it has none
+func (i *Item) Get(slug string) (Value, bool) {
+	for _, change := range i.changes {
+		if change.Slug == slug {
+			value := Value{Data: change.Data}
+			if attribute, ok := i.attribute(slug); ok {
+				value.Type = attribute.Type
+			}
+
+			return value, !value.IsNull()
+		}
+	}
+
+	for _, value := range i.values {
+		if value.Slug == slug {
+			return value.Value, !value.IsNull()
+		}
+	}
+
+	return Value{}, false
+}
+
+// Set records v as the new value of the attribute with slug, it is checked and written by Save
+func (i *Item) Set(slug string, v any) {
+	for idx, change := range i.changes {
+		if change.Slug == slug {
+			i.changes[idx].Data = v
+			return
+		}
+	}
+
+	i.changes = append(i.changes, Change{Slug: slug, Data: v})
+}
+
+// Unset records that the value of the attribute with slug is removed by Save
+func (i *Item) Unset(slug string) {
+	i.Set(slug, nil)
+}
+
+// Changes returns the changes made by Set and Unset since i was loaded or saved, in the order they were first made
+func (i *Item) Changes() []Change {
+	return slices.Clone(i.changes)
+}
+
+// attribute returns the attribute with slug linked to the loaded entity of i
+func (i *Item) attribute(slug string) (EntityAttributeEmbed, bool) {
+	if i.entity == nil {
+		return EntityAttributeEmbed{}, false
+	}
+
+	return i.entity.attribute(slug)
+}
+
+// Save writes the changes of i in one transaction, creating i first when it has no ID yet.
+// Nothing is written unless every change is to an attribute linked to the entity of i with a value of its type,
+// and every required attribute has a value once the changes are made
+func (i *Item) Save(ctx context.Context, q *Queries) error {
+	if !i.loadedEntity || !i.loadedValues {
+		if err := i.Load(ctx, q); err != nil {
+			return err
+		}
+	}
+
+	values := make([]Value, len(i.changes))
+	attributeIds := make([]int64, len(i.changes))
+	for idx, change := range i.changes {
+		attribute, ok := i.attribute(change.Slug)
+		if !ok && change.Data == nil {
+			// Attributes not linked to the entity have no value to unset, left without an id to be skipped
+			continue
+		}
+
+		if !ok {
+			return &ValidationError{Field: change.Slug, Err: fmt.Errorf("%w: not linked to %s", ErrNotFound, i.entity.Slug)}
+		}
+
+		values[idx] = Value{Type: attribute.Type, Data: change.Data}
+		attributeIds[idx] = attribute.ID
+
+		if values[idx].IsNull() {
+			continue
+		}
+
+		stored, err := encodeValue(values[idx])
+		if err != nil {
+			return &ValidationError{Field: change.Slug, Value: change.Data, Err: fmt.Errorf("%w: %w", ErrInvalidType, err)}
+		}
+
+		// Held as it reads back once saved, like an int64 for an int set on an int64 attribute
+		values[idx], err = decodeValue(attribute.Type, stored)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, attribute := range i.entity.attributes {
+		if _, ok := i.Get(attribute.Slug); attribute.Required && !ok {
+			return &ValidationError{Field: attribute.Slug, Err: ErrRequiredMissing}
+		}
+	}
+
+	var id int64
+	err := q.RunInTx(ctx, func(q *Queries) error {
+		// Set again when the transaction is retried
+		id = i.ID
+
+		if id == 0 {
+			item, err := q.CreateItem(ctx, i.EntityID)
+			if err != nil {
+				return err
+			}
+
+			id = item.ID
+		}
+
+		for idx, value := range values {
+			if attributeIds[idx] == 0 {
+				continue
+			}
+
+			if !value.IsNull() {
+				if err := q.SetItemValue(ctx, id, attributeIds[idx], value); err != nil {
+					return fmt.Errorf("Failed to set %s on item %v: %w", i.changes[idx].Slug, id, err)
+				}
+				continue
+			}
+
+			// A value that was never set is already unset
+			err := q.DeleteItemAttributes(ctx, id, attributeIds[idx])
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("Failed to unset %s on item %v: %w", i.changes[idx].Slug, id, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	i.ID = id
+	for idx, value := range values {
+		slug := i.changes[idx].Slug
+		i.values = slices.DeleteFunc(i.values, func(v ItemValue) bool { return v.Slug == slug })
+
+		if !value.IsNull() {
+			i.values = append(i.values, ItemValue{ItemID: id, AttributeID: attributeIds[idx], Slug: slug, Value: value})
+		}
+	}
+
+	i.changes = nil
+	return nil
+}
+
+// data returns the Value of ia without the pointer it may be held in, nil for a nil pointer
+func (ia *ItemAttribute[T]) data() any {
+	rv := reflect.ValueOf(any(ia.Value))
+	if rv.Kind() != reflect.Pointer {
+		return ia.Value
+	}
+
+	if rv.IsNil() {
+		return nil
+	}
+
+	return rv.Elem().Interface()
+}
+
+// storedValue is the Value of ia as written to the database, encoded like SetItemValue when Type is set,
+// without a Type times are stored as datetime and anything else is left to the driver
+func (ia *ItemAttribute[T]) storedValue() (any, error) {
+	data := ia.data()
+	if ia.Type != "" {
+		stored, err := encodeValue(Value{ia.Type, data})
+		if err != nil {
+			return nil, &ValidationError{Field: "value", Value: data, Err: fmt.Errorf("%w: %w", ErrInvalidType, err)}
+		}
+
+		return stored, nil
+	}
+
+	if tm, ok := data.(time.Time); ok {
+		return encodeTime(DatetimeType, tm), nil
+	}
+
+	return data, nil
+}
+
+// setData sets the Value of ia to data, through a new pointer when T is one
+func (ia *ItemAttribute[T]) setData(data any) error {
+	rv := reflect.ValueOf(&ia.Value).Elem()
+	if data == nil {
+		rv.SetZero()
+		return nil
+	}
+
+	dv := reflect.ValueOf(data)
+	switch {
+	case dv.Type().AssignableTo(rv.Type()):
+		rv.Set(dv)
+	case rv.Kind() == reflect.Pointer && dv.Type().AssignableTo(rv.Type().Elem()):
+		ptr := reflect.New(rv.Type().Elem())
+		ptr.Elem().Set(dv)
+		rv.Set(ptr)
+	default:
+		return fmt.Errorf("%s can not be loaded into %s", ia.Type, rv.Type())
+	}
+
+	return nil
+}
+
+const createItemAttribute = `
+INSERT INTO item_attribute (item_id, attribute_id, value)
+SELECT id, ?, ? FROM items WHERE id = ? AND tenant_id = ?;
+`
+
 func (ia *ItemAttribute[T]) Create(ctx context.Context, q *Queries) error {
-	_, err := q.db.ExecContext(ctx, "INSERT INTO item_attribute (item_id, attribute_id, value) VALUES(?, ?, ?);",
-		ia.ItemID,
+	stored, err := ia.storedValue()
+	if err != nil {
+		return err
+	}
+
+	return q.execOne(ctx, createItemAttribute,
 		ia.AttributeID,
-		ia.Value,
+		stored,
+		ia.ItemID,
+		q.tenant,
 	)
-	return err
 }
 
+const updateItemAttribute = `
+UPDATE item_attribute SET value = ? WHERE item_id = ? AND attribute_id = ? AND item_id IN (SELECT id FROM items WHERE tenant_id = ?);
+`
+
 func (ia *ItemAttribute[T]) Update(ctx context.Context, q *Queries) error {
-	_, err := q.db.ExecContext(ctx, "UPDATE item_attribute SET value = ? WHERE item_id = ? AND attribute_id = ?",
-		ia.Value,
+	stored, err := ia.storedValue()
+	if err != nil {
+		return err
+	}
+
+	return q.execOne(ctx, updateItemAttribute,
+		stored,
 		ia.ItemID,
 		ia.AttributeID,
+		q.tenant,
 	)
-	return err
 }
 
+// Load reads the value of ia, decoded into the Go type of Type (see Value) when it is set,
+// T must be that type, a pointer to it or any
 func (ia *ItemAttribute[T]) Load(ctx context.Context, q *Queries) error {
-	row := q.db.QueryRowContext(ctx, "SELECT value FROM item_attribute WHERE item_id = ? AND attribute_id = ?", ia.ItemID, ia.AttributeID)
-
-	switch(ia.Type) {
-	case TimeType: fallthrough
-	case DateType: fallthrough
-	case DatetimeType:
-		var i *string
-		err := row.Scan(&i)
-		if err != nil || i == nil {
-			return err
-		}
+	row := q.queryRowContext(ctx, getItemValue, ia.ItemID, ia.AttributeID, q.tenant)
 
-		time, err := time.Parse("2006-01-02 15:04:05 -0700 MST", *i)
-		if err != nil {
-			return err
-		}
+	if ia.Type == "" {
+		return classify(row.Scan(&ia.Value))
+	}
 
-		// Generics bs handling...
-		ia.Value = any(&time).(T)
-		return nil
-	default:
-		return row.Scan(&ia.Value)
+	var raw any
+	if err := row.Scan(&raw); err != nil {
+		return classify(err)
+	}
+
+	v, err := decodeValue(ia.Type, raw)
+	if err != nil {
+		return err
 	}
+
+	return ia.setData(v.Data)
 }
 
 const createItem = `
-INSERT INTO items (entity_id) VALUES (?)
-RETURNING *;
+INSERT INTO items (entity_id, tenant_id) VALUES (?, ?)
+RETURNING id, entity_id;
 `
 
 func (q *Queries) CreateItem(ctx context.Context, entityId int64) (Item, error) {
-	row := q.db.QueryRowContext(ctx, createItem, entityId)
+	row := q.queryRowContext(ctx, createItem, entityId, q.tenant)
 
 	var i Item
 	err := row.Scan(
@@ -109,54 +376,52 @@ func (q *Queries) CreateItem(ctx context.Context, entityId int64) (Item, error)
 		&i.EntityID,
 	)
 
-	return i, err
+	return i, classify(err)
 }
 
 const updateItemEntityID = `
-UPDATE items SET entity_id = ? WHERE id = ?;
+UPDATE items SET entity_id = ? WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) UpdateItemEntityID(ctx context.Context, entityId int64, itemId int64) error {
-	_, err := q.db.ExecContext(ctx, updateItemEntityID, entityId, itemId)
-	return err
+	return q.execOne(ctx, updateItemEntityID, entityId, itemId, q.tenant)
 }
 
 
 const deleteItem = `
-DELETE FROM items WHERE id = ?;
+DELETE FROM items WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) DeleteItem(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteItem, id)
-	return err
+	return q.execOne(ctx, deleteItem, id, q.tenant)
 }
 
 const getItem = `
-SELECT * FROM items WHERE id = ?;
+SELECT id, entity_id FROM items WHERE id = ? AND tenant_id = ?;
 `
 
 func (q *Queries) GetItem(ctx context.Context, id int64) (Item, error) {
-	row := q.db.QueryRowContext(ctx, getItem, id)
+	row := q.queryRowContext(ctx, getItem, id, q.tenant)
 
 	var i Item
 	if err := row.Scan(
 		&i.ID,
 		&i.EntityID,
 	); err != nil {
-		return i, err
+		return i, classify(err)
 	}
 
 	return i, nil
 }
 
 const listItems = `
-SELECT * FROM items;
+SELECT id, entity_id FROM items WHERE tenant_id = ?;
 `
 
 func (q *Queries) ListItems(ctx context.Context) ([]Item, error) {
-	rows, err := q.db.QueryContext(ctx, listItems)
+	rows, err := q.queryContext(ctx, listItems, q.tenant)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 	defer rows.Close()
 
@@ -168,26 +433,26 @@ func (q *Queries) ListItems(ctx context.Context) ([]Item, error) {
 			&i.ID,
 			&i.EntityID,
 		); err != nil {
-			return items, err
+			return items, classify(err)
 		}
 
 		items = append(items, i)
 	}
 
-	return items, nil
+	return items, classify(rows.Err())
 }
 
 const listItemAttributesByItem = `
 SELECT item_id, attribute_id, value, type
 FROM item_attribute
 LEFT JOIN attributes ON attributes.id = item_attribute.attribute_id
-WHERE item_id = ?;
+WHERE item_id = ? AND item_id IN (SELECT id FROM items WHERE tenant_id = ?);
 `
 
 func (q *Queries) ListItemAttributes(ctx context.Context, itemId int64) ([]ItemAttribute[*any], error) {
-	rows, err := q.db.QueryContext(ctx, listItemAttributesByItem, itemId)
+	rows, err := q.queryContext(ctx, listItemAttributesByItem, itemId, q.tenant)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 	defer rows.Close()
 
@@ -195,46 +460,170 @@ func (q *Queries) ListItemAttributes(ctx context.Context, itemId int64) ([]ItemA
 	for rows.Next() {
 		var i ItemAttribute[*any]
 		var attributeType string
+		var raw any
 
 		if err := rows.Scan(
 			&i.ItemID,
 			&i.AttributeID,
-			&i.Value,
+			&raw,
 			&attributeType,
 		); err != nil {
+			return items, classify(err)
+		}
+
+		i.Type = AttributeType(attributeType)
+
+		value, err := decodeValue(i.Type, raw)
+		if err != nil {
+			return items, fmt.Errorf("Failed to read attribute %v on item %v: %w", i.AttributeID, i.ItemID, err)
+		}
+
+		if err := i.setData(value.Data); err != nil {
 			return items, err
 		}
 
 		items = append(items, i)
 	}
 
-	return items, nil
+	return items, classify(rows.Err())
+}
+
+type ItemValue struct {
+	ItemID int64
+	AttributeID int64
+	Slug string
+	Value
+}
+
+const listItemValuesByItem = `
+SELECT item_attribute.item_id, item_attribute.attribute_id, attributes.slug, attributes.type, item_attribute.value
+FROM item_attribute
+INNER JOIN attributes ON attributes.id = item_attribute.attribute_id
+WHERE item_attribute.item_id = ? AND attributes.tenant_id = ?
+ORDER BY item_attribute.attribute_id;
+`
+
+// ListItemValues lists the values of an item decoded into the Go type of their attribute
+func (q *Queries) ListItemValues(ctx context.Context, itemId int64) ([]ItemValue, error) {
+	rows, err := q.queryContext(ctx, listItemValuesByItem, itemId, q.tenant)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer rows.Close()
+
+	return scanItemValues(rows)
+}
+
+const listAllItemValues = `
+SELECT item_attribute.item_id, item_attribute.attribute_id, attributes.slug, attributes.type, item_attribute.value
+FROM item_attribute
+INNER JOIN attributes ON attributes.id = item_attribute.attribute_id
+WHERE attributes.tenant_id = ?
+ORDER BY item_attribute.item_id, item_attribute.attribute_id;
+`
+
+// ListAllItemValues lists the values of every item, ordered by item
+func (q *Queries) ListAllItemValues(ctx context.Context) ([]ItemValue, error) {
+	rows, err := q.queryContext(ctx, listAllItemValues, q.tenant)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer rows.Close()
+
+	return scanItemValues(rows)
+}
+
+func scanItemValues(rows *sql.Rows) ([]ItemValue, error) {
+	var items []ItemValue
+	for rows.Next() {
+		var i ItemValue
+		var attributeType string
+		var raw any
+
+		if err := rows.Scan(
+			&i.ItemID,
+			&i.AttributeID,
+			&i.Slug,
+			&attributeType,
+			&raw,
+		); err != nil {
+			return items, classify(err)
+		}
+
+		value, err := decodeValue(AttributeType(attributeType), raw)
+		if err != nil {
+			return items, fmt.Errorf("Failed to read %s on item %v: %w", i.Slug, i.ItemID, err)
+		}
+
+		i.Value = value
+		items = append(items, i)
+	}
+
+	return items, classify(rows.Err())
+}
+
+const setItemValue = `
+INSERT INTO item_attribute (item_id, attribute_id, value)
+SELECT id, ?, ? FROM items WHERE id = ? AND tenant_id = ?
+ON CONFLICT (item_id, attribute_id) DO UPDATE SET value = excluded.value;
+`
+
+// SetItemValue stores value on an item, whether or not the item already has a value for the attribute,
+// integers may be of any Go integer type as long as they are in range of value.Type
+func (q *Queries) SetItemValue(ctx context.Context, itemId int64, attributeId int64, value Value) error {
+	stored, err := encodeValue(value)
+	if err != nil {
+		return &ValidationError{Field: "value", Value: value.Data, Err: fmt.Errorf("%w: %w", ErrInvalidType, err)}
+	}
+
+	return q.execOne(ctx, setItemValue, attributeId, stored, itemId, q.tenant)
 }
 
 const deleteItemAttribute = `
-DELETE FROM item_attribute WHERE item_id = ? AND attribute_id = ?;
+DELETE FROM item_attribute WHERE item_id = ? AND attribute_id = ? AND item_id IN (SELECT id FROM items WHERE tenant_id = ?);
 `
 
 func (q *Queries) DeleteItemAttributes(ctx context.Context, itemId int64, attributeId int64) error {
-	_, err := q.db.ExecContext(ctx, deleteItemAttributesByItem, itemId, attributeId)
-	return err
+	return q.execOne(ctx, deleteItemAttribute, itemId, attributeId, q.tenant)
 }
 
 
 const deleteItemAttributesByItem = `
-DELETE FROM item_attribute WHERE item_id = ?
+DELETE FROM item_attribute WHERE item_id = ? AND item_id IN (SELECT id FROM items WHERE tenant_id = ?)
 `
 
 func (q *Queries) DeleteItemAttributesByItem(ctx context.Context, itemId int64) error {
-	_, err := q.db.ExecContext(ctx, deleteItemAttributesByItem, itemId)
-	return err
+	return q.exec(ctx, deleteItemAttributesByItem, itemId, q.tenant)
+}
+
+const deleteItemAttributesByEntityAttribute = `
+DELETE FROM item_attribute WHERE attribute_id = ? AND item_id IN (SELECT id FROM items WHERE entity_id = ? AND tenant_id = ?)
+`
+
+func (q *Queries) DeleteItemAttributesByEntityAttribute(ctx context.Context, entityId int64, attributeId int64) error {
+	return q.exec(ctx, deleteItemAttributesByEntityAttribute, attributeId, entityId, q.tenant)
+}
+
+const deleteItemAttributesByEntity = `
+DELETE FROM item_attribute WHERE item_id IN (SELECT id FROM items WHERE entity_id = ? AND tenant_id = ?)
+`
+
+func (q *Queries) DeleteItemAttributesByEntity(ctx context.Context, entityId int64) error {
+	return q.exec(ctx, deleteItemAttributesByEntity, entityId, q.tenant)
+}
+
+const deleteItemsByEntity = `
+DELETE FROM items WHERE entity_id = ? AND tenant_id = ?
+`
+
+func (q *Queries) DeleteItemsByEntity(ctx context.Context, entityId int64) error {
+	return q.exec(ctx, deleteItemsByEntity, entityId, q.tenant)
 }
 
 const deleteItemAttributesByAttribute = `
-DELETE FROM item_attribute WHERE attribute_id = ?
+DELETE FROM item_attribute WHERE attribute_id = ? AND item_id IN (SELECT id FROM items WHERE tenant_id = ?)
 `
 
 func (q *Queries) DeleteItemAttributesByAttribute(ctx context.Context, attributeId int64) error {
-	_, err := q.db.ExecContext(ctx, deleteItemAttributesByAttribute, attributeId)
-	return err
+	return q.exec(ctx, deleteItemAttributesByAttribute, attributeId, q.tenant)
 }
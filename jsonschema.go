@@ -0,0 +1,435 @@
+package geaves
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDialect is the draft of the JSON Schemas written by GenerateJSONSchema
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaTypes is the type keyword, written as a single string when it holds one type
+type JSONSchemaTypes []string
+
+func (t JSONSchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+
+	return json.Marshal([]string(t))
+}
+
+func (t *JSONSchemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = JSONSchemaTypes{single}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (t JSONSchemaTypes) has(typ string) bool {
+	for _, v := range t {
+		if v == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// JSONSchema holds the keywords of a JSON Schema geaves reads and writes, other keywords are ignored when reading
+type JSONSchema struct {
+	Schema string `json:"$schema,omitempty"`
+	ID string `json:"$id,omitempty"`
+	Title string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type JSONSchemaTypes `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	Minimum *json.Number `json:"minimum,omitempty"`
+	Maximum *json.Number `json:"maximum,omitempty"`
+	Properties map[string]*JSONSchema `json:"properties,omitempty"`
+	Required []string `json:"required,omitempty"`
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+	Defs map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// Pattern of time values, which are a partial-time without the offset the time format requires
+const jsonTimePattern = `^[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?$`
+
+// jsonRange is the range of the integer types, as json numbers
+func jsonRange(t AttributeType) (string, string) {
+	switch t {
+	case Int8Type:
+		return strconv.Itoa(math.MinInt8), strconv.Itoa(math.MaxInt8)
+	case Int16Type:
+		return strconv.Itoa(math.MinInt16), strconv.Itoa(math.MaxInt16)
+	case Int32Type, RuneType:
+		return strconv.Itoa(math.MinInt32), strconv.Itoa(math.MaxInt32)
+	case IntType, Int64Type:
+		return strconv.FormatInt(math.MinInt64, 10), strconv.FormatInt(math.MaxInt64, 10)
+	case Uint8Type, ByteType:
+		return "0", strconv.Itoa(math.MaxUint8)
+	case Uint16Type:
+		return "0", strconv.Itoa(math.MaxUint16)
+	case Uint32Type:
+		return "0", strconv.FormatUint(math.MaxUint32, 10)
+	case UintType, Uint64Type:
+		return "0", strconv.FormatUint(math.MaxUint64, 10)
+	default:
+		return "", ""
+	}
+}
+
+// jsonDecimalPattern is the pattern of decimal and money values of type t, which no other precision and scale
+// share so JSONSchemaAttributeType can read them back from it
+func jsonDecimalPattern(t AttributeType) string {
+	precision, scale, _ := t.DecimalParams()
+
+	pattern := fmt.Sprintf(`^-?[0-9]{1,%d}`, precision - scale)
+	if precision == scale {
+		pattern = `^-?0`
+	}
+
+	if scale > 0 {
+		pattern += fmt.Sprintf(`(\.[0-9]{1,%d})?`, scale)
+	}
+
+	if t.Base() == MoneyType {
+		pattern += ` [A-Z]{3}`
+	}
+
+	return pattern + "$"
+}
+
+// attributeJSONSchema is the schema values of attribute are written with by Value.MarshalJSON, optional
+// attributes also allow null
+func attributeJSONSchema(attribute EntityAttributeEmbed) *JSONSchema {
+	typ, format := attribute.Type.JSONType()
+	s := &JSONSchema{Title: attribute.Name, Type: JSONSchemaTypes{typ}}
+
+	switch attribute.Type {
+	case BlobType:
+		s.ContentEncoding = "base64"
+	case TimeType:
+		s.Pattern = jsonTimePattern
+	case DateType, DatetimeType, Float32Type, Float64Type:
+		s.Format = format
+	}
+
+	if _, _, ok := attribute.Type.DecimalParams(); ok {
+		s.Format = format
+		s.Pattern = jsonDecimalPattern(attribute.Type)
+	}
+
+	if min, max := jsonRange(attribute.Type); min != "" {
+		minimum, maximum := json.Number(min), json.Number(max)
+		s.Minimum, s.Maximum = &minimum, &maximum
+	}
+
+	if attribute.Type == ByteType || attribute.Type == RuneType {
+		// Written as their number, one character in the text format
+		s.Description = fmt.Sprintf("%s as a number", attribute.Type)
+	}
+
+	if !attribute.Required {
+		s.Type = append(s.Type, "null")
+	}
+
+	return s
+}
+
+// GenerateJSONSchema returns a JSON Schema (draft 2020-12) of the values of an item of entity, as an object
+// by attribute slug which holds every required attribute and nothing that is not linked to the entity
+func GenerateJSONSchema(ctx context.Context, q *Queries, entity Entity) (*JSONSchema, error) {
+	attributes, err := entity.GetAttributes(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get attributes of %s: %w", entity.Slug, err)
+	}
+
+	closed := false
+	s := &JSONSchema{
+		Schema: JSONSchemaDialect,
+		ID: entity.Slug,
+		Title: entity.Name,
+		Type: JSONSchemaTypes{"object"},
+		Properties: map[string]*JSONSchema{},
+		Required: []string{},
+		AdditionalProperties: &closed,
+	}
+
+	for _, attribute := range attributes {
+		s.Properties[attribute.Slug] = attributeJSONSchema(attribute)
+		if attribute.Required {
+			s.Required = append(s.Required, attribute.Slug)
+		}
+	}
+	slices.Sort(s.Required)
+
+	return s, nil
+}
+
+// GenerateJSONSchemas returns a JSON Schema with the schema of every entity under $defs by entity slug
+func GenerateJSONSchemas(ctx context.Context, q *Queries) (*JSONSchema, error) {
+	entities, err := q.ListEntities(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list entities: %w", err)
+	}
+
+	s := &JSONSchema{Schema: JSONSchemaDialect, Defs: map[string]*JSONSchema{}}
+	for _, entity := range entities {
+		def, err := GenerateJSONSchema(ctx, q, entity)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only the root of a document declares the dialect, and the slug is the key
+		def.Schema = ""
+		def.ID = ""
+		s.Defs[entity.Slug] = def
+	}
+
+	return s, nil
+}
+
+func WriteJSONSchema(w io.Writer, s *JSONSchema) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func ReadJSONSchema(r io.Reader) (*JSONSchema, error) {
+	var s JSONSchema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("Failed to read json schema: %w", err)
+	}
+
+	return &s, nil
+}
+
+// JSONSchemaAttributeType picks the attribute type for values matching s, integers get the smallest type
+// whose range matches minimum and maximum exactly and int64 otherwise. As byte, rune, int and uint share
+// their ranges with uint8, int32, int64 and uint64 those are never picked
+func JSONSchemaAttributeType(s *JSONSchema) (AttributeType, error) {
+	var types []string
+	for _, t := range s.Type {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+
+	if len(types) != 1 {
+		return "", fmt.Errorf("Needs exactly one type besides null, got %v", s.Type)
+	}
+
+	switch types[0] {
+	case "boolean":
+		return BoolType, nil
+	case "string":
+		switch {
+		case s.Format == "decimal" || s.Format == "money":
+			base := NewDecimalType
+			if s.Format == "money" {
+				base = NewMoneyType
+			}
+
+			for precision := 1; precision <= MaxDecimalPrecision; precision++ {
+				for scale := 0; scale <= precision; scale++ {
+					if t := base(precision, scale); jsonDecimalPattern(t) == s.Pattern {
+						return t, nil
+					}
+				}
+			}
+
+			return "", fmt.Errorf("Pattern %s of %s does not give its precision and scale", s.Pattern, s.Format)
+		case s.ContentEncoding == "base64" || s.Format == "byte":
+			return BlobType, nil
+		case s.Format == "date":
+			return DateType, nil
+		case s.Format == "date-time":
+			return DatetimeType, nil
+		case s.Format == "time" || s.Pattern == jsonTimePattern:
+			return TimeType, nil
+		default:
+			return StringType, nil
+		}
+	case "number":
+		if s.Format == "float" {
+			return Float32Type, nil
+		}
+		return Float64Type, nil
+	case "integer":
+		if s.Minimum == nil || s.Maximum == nil {
+			return Int64Type, nil
+		}
+
+		for _, t := range []AttributeType{Int8Type, Uint8Type, Int16Type, Uint16Type, Int32Type, Uint32Type, Int64Type, Uint64Type} {
+			if min, max := jsonRange(t); min == s.Minimum.String() && max == s.Maximum.String() {
+				return t, nil
+			}
+		}
+
+		return Int64Type, nil
+	default:
+		return "", fmt.Errorf("Unsupported type %s", types[0])
+	}
+}
+
+type JSONSchemaImportOptions struct {
+	// Name of the entity, defaults to the title of the schema and then the slug
+	Name string
+	// Slug of the entity, defaults to the last segment of $id without extension
+	Slug string
+}
+
+type JSONSchemaImportResult struct {
+	Entity Entity
+	EntityCreated bool
+	AttributesCreated []string
+	LinksCreated []string
+	LinksUpdated []string
+}
+
+// jsonSchemaSlug takes the slug from an $id such as product, product.json or https://example.com/schemas/product.schema.json
+func jsonSchemaSlug(id string) string {
+	slug := path.Base(strings.TrimRight(id, "/#"))
+	if idx := strings.Index(slug, "."); idx > 0 {
+		slug = slug[:idx]
+	}
+
+	if slug == "." || slug == "/" {
+		return ""
+	}
+
+	return slug
+}
+
+// ImportJSONSchema creates an entity from an object schema, with an attribute per property named by its title
+// (or slug) and linked as required when listed in required. An entity or attributes that already exist (by slug)
+// are reused, attributes must then have the type the property maps to by JSONSchemaAttributeType.
+// Nothing is kept of an import that fails, it runs in one transaction (see RunInTx)
+func ImportJSONSchema(ctx context.Context, q *Queries, s *JSONSchema, opts JSONSchemaImportOptions) (JSONSchemaImportResult, error) {
+	var res JSONSchemaImportResult
+	err := q.RunInTx(ctx, func(q *Queries) error {
+		var err error
+		res, err = importJSONSchema(ctx, q, s, opts)
+		return err
+	})
+
+	return res, err
+}
+
+func importJSONSchema(ctx context.Context, q *Queries, s *JSONSchema, opts JSONSchemaImportOptions) (JSONSchemaImportResult, error) {
+	var res JSONSchemaImportResult
+
+	if !s.Type.has("object") {
+		return res, errors.New("Only an object schema can be imported as an entity")
+	}
+
+	slug := opts.Slug
+	if slug == "" {
+		slug = jsonSchemaSlug(s.ID)
+	}
+	if slug == "" {
+		return res, errors.New("Schema has no $id to take the slug of the entity from, please give one")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = s.Title
+	}
+	if name == "" {
+		name = slug
+	}
+
+	required := make(map[string]bool, len(s.Required))
+	for _, attrSlug := range s.Required {
+		if _, ok := s.Properties[attrSlug]; !ok {
+			return res, fmt.Errorf("%s is required, but not a property", attrSlug)
+		}
+
+		required[attrSlug] = true
+	}
+
+	// Work out every type before writing anything
+	types := make(map[string]AttributeType, len(s.Properties))
+	for attrSlug, prop := range s.Properties {
+		t, err := JSONSchemaAttributeType(prop)
+		if err != nil {
+			return res, fmt.Errorf("Property %s: %w", attrSlug, err)
+		}
+
+		types[attrSlug] = t
+	}
+
+	entity, err := q.GetEntity(ctx, GetEntityParam{Field: BySlug, Value: slug})
+	if errors.Is(err, sql.ErrNoRows) {
+		entity, err = q.CreateEntity(ctx, CreateEntityParam{name, slug})
+		res.EntityCreated = true
+	}
+	if err != nil {
+		return res, fmt.Errorf("Failed to import entity %s: %w", slug, err)
+	}
+	res.Entity = entity
+
+	linked, err := q.LoadAttributesByEntity(ctx, entity.ID)
+	if err != nil {
+		return res, fmt.Errorf("Failed to get attributes of %s: %w", slug, err)
+	}
+
+	for _, attrSlug := range slices.Sorted(maps.Keys(s.Properties)) {
+		prop := s.Properties[attrSlug]
+
+		attribute, err := q.GetAttribute(ctx, GetAttributeParam{Field: BySlug, Value: attrSlug})
+		if errors.Is(err, sql.ErrNoRows) {
+			attrName := prop.Title
+			if attrName == "" {
+				attrName = attrSlug
+			}
+
+			attribute, err = q.CreateAttribute(ctx, CreateAttributeParam{attrName, attrSlug, types[attrSlug]})
+			res.AttributesCreated = append(res.AttributesCreated, attrSlug)
+		}
+		if err != nil {
+			return res, fmt.Errorf("Failed to import attribute %s: %w", attrSlug, err)
+		}
+
+		if attribute.Type != types[attrSlug] {
+			return res, fmt.Errorf("Attribute %s already exists as %s, the schema has it as %s", attrSlug, attribute.Type, types[attrSlug])
+		}
+
+		link := -1
+		for idx, l := range linked {
+			if l.ID == attribute.ID {
+				link = idx
+			}
+		}
+
+		switch {
+		case link < 0:
+			_, err = q.CreateEntityAttribute(ctx, EntityAttribute{entity.ID, attribute.ID, required[attrSlug]})
+			res.LinksCreated = append(res.LinksCreated, attrSlug)
+		case linked[link].Required != required[attrSlug]:
+			err = q.UpdateRequireEntityAttribute(ctx, required[attrSlug], entity.ID, attribute.ID)
+			res.LinksUpdated = append(res.LinksUpdated, attrSlug)
+		}
+		if err != nil {
+			return res, fmt.Errorf("Failed to link %s to %s: %w", attrSlug, slug, err)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package geaves
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+const listStoredValues = `
+SELECT item_attribute.item_id, item_attribute.attribute_id, item_attribute.value, attributes.type
+FROM item_attribute
+JOIN attributes ON attributes.id = item_attribute.attribute_id
+WHERE item_attribute.value IS NOT NULL AND attributes.tenant_id = ?
+ORDER BY item_attribute.item_id, item_attribute.attribute_id;
+`
+
+const updateStoredValue = `
+UPDATE item_attribute SET value = ? WHERE item_id = ? AND attribute_id = ?;
+`
+
+type storedValue struct {
+	itemID int64
+	attributeID int64
+	raw any
+	encoded any
+}
+
+// MigrateValues rewrites every stored value of the tenant of q (see ForTenant) that is not in the layout it is written in now, like date, time
+// and datetime values stored before they had one, returning how many were rewritten.
+// Values already stored as they would be written are left alone, so it can be run any number of times,
+// q should be bound to a transaction (see RunInTx) so a value that can not be read rolls back the whole migration
+func MigrateValues(ctx context.Context, q *Queries) (int, error) {
+	rows, err := q.queryContext(ctx, listStoredValues, q.tenant)
+	if err != nil {
+		return 0, classify(err)
+	}
+	defer rows.Close()
+
+	// Rewritten once every value is read, the rows hold the connection until then
+	var rewrite []storedValue
+	for rows.Next() {
+		var stored storedValue
+		var t AttributeType
+
+		if err := rows.Scan(&stored.itemID, &stored.attributeID, &stored.raw, &t); err != nil {
+			return 0, classify(err)
+		}
+
+		v, err := decodeValue(t, stored.raw)
+		if err != nil {
+			return 0, fmt.Errorf("Failed to read %s value of attribute %v on item %v: %w", t, stored.attributeID, stored.itemID, err)
+		}
+
+		stored.encoded, err = encodeValue(v)
+		if err != nil {
+			return 0, fmt.Errorf("Failed to encode %s value of attribute %v on item %v: %w", t, stored.attributeID, stored.itemID, err)
+		}
+
+		if !sameStored(stored.raw, stored.encoded) {
+			rewrite = append(rewrite, stored)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, classify(err)
+	}
+	rows.Close()
+
+	for _, stored := range rewrite {
+		if err := q.exec(ctx, updateStoredValue, stored.encoded, stored.itemID, stored.attributeID); err != nil {
+			return 0, fmt.Errorf("Failed to rewrite value of attribute %v on item %v: %w", stored.attributeID, stored.itemID, err)
+		}
+	}
+
+	return len(rewrite), nil
+}
+
+// sameStored reports whether raw, as read back from the value column, is what encoded is stored as
+func sameStored(raw any, encoded any) bool {
+	switch e := encoded.(type) {
+	case bool:
+		// sqlite has no booleans, they are stored as 0 and 1
+		i, ok := raw.(int64)
+		return ok && (i != 0) == e
+	case []byte:
+		b, ok := raw.([]byte)
+		return ok && bytes.Equal(b, e)
+	default:
+		return raw == encoded
+	}
+}
@@ -0,0 +1,147 @@
+package geaves
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type Operator string
+const (
+	OpEq Operator = "="
+	OpNe Operator = "!="
+	OpLt Operator = "<"
+	OpLe Operator = "<="
+	OpGt Operator = ">"
+	OpGe Operator = ">="
+	OpIsNull Operator = "is-null"
+	OpNotNull Operator = "not-null"
+	OpBetween Operator = "between"
+)
+
+// Range is the Value of OpBetween, both ends are inclusive and an end left nil is open,
+// date, time and datetime are compared in time order with datetimes of any location compared as instants
+type Range struct {
+	From any
+	To any
+}
+
+// Predicate filters items on the value they hold for the attribute with slug Attribute,
+// Value must be of the Go type of the attribute (see Value), a Range for OpBetween, and is ignored for OpIsNull and OpNotNull
+type Predicate struct {
+	Attribute string
+	Op Operator
+	Value any
+}
+
+type ItemQuery struct {
+	EntityID int64
+	Where []Predicate
+}
+
+// whereItems builds the sql conditions (joined by AND) matching items against preds,
+// the conditions reference the items table by its name
+func (q *Queries) whereItems(ctx context.Context, preds []Predicate) ([]string, []any, error) {
+	var conds []string
+	var args []any
+
+	for _, pred := range preds {
+		attribute, err := q.GetAttribute(ctx, GetAttributeParam{Field: BySlug, Value: pred.Attribute})
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to get attribute '%s' to filter on: %w", pred.Attribute, err)
+		}
+
+		switch pred.Op {
+		case OpIsNull:
+			conds = append(conds, "NOT EXISTS (SELECT 1 FROM item_attribute WHERE item_attribute.item_id = items.id AND item_attribute.attribute_id = ? AND item_attribute.value IS NOT NULL)")
+			args = append(args, attribute.ID)
+		case OpNotNull:
+			conds = append(conds, "EXISTS (SELECT 1 FROM item_attribute WHERE item_attribute.item_id = items.id AND item_attribute.attribute_id = ? AND item_attribute.value IS NOT NULL)")
+			args = append(args, attribute.ID)
+		case OpEq, OpNe, OpLt, OpLe, OpGt, OpGe:
+			if pred.Value == nil {
+				return nil, nil, fmt.Errorf("'%s' on %s needs a value, use %s or %s to match missing values", pred.Op, pred.Attribute, OpIsNull, OpNotNull)
+			}
+
+			stored, err := encodeValue(Value{attribute.Type, pred.Value})
+			if err != nil {
+				return nil, nil, &ValidationError{Field: pred.Attribute, Value: pred.Value, Err: fmt.Errorf("%w: %w", ErrInvalidType, err)}
+			}
+
+			conds = append(conds, fmt.Sprintf("EXISTS (SELECT 1 FROM item_attribute WHERE item_attribute.item_id = items.id AND item_attribute.attribute_id = ? AND item_attribute.value %s ?)", pred.Op))
+			args = append(args, attribute.ID, stored)
+		case OpBetween:
+			r, ok := pred.Value.(Range)
+			if !ok || (r.From == nil && r.To == nil) {
+				return nil, nil, fmt.Errorf("'%s' on %s needs a Range with at least one end", pred.Op, pred.Attribute)
+			}
+
+			var bounds []string
+			var boundArgs []any
+			for _, bound := range []struct{ op Operator; value any }{{OpGe, r.From}, {OpLe, r.To}} {
+				if bound.value == nil {
+					continue
+				}
+
+				stored, err := encodeValue(Value{attribute.Type, bound.value})
+				if err != nil {
+					return nil, nil, &ValidationError{Field: pred.Attribute, Value: bound.value, Err: fmt.Errorf("%w: %w", ErrInvalidType, err)}
+				}
+
+				bounds = append(bounds, fmt.Sprintf("item_attribute.value %s ?", bound.op))
+				boundArgs = append(boundArgs, stored)
+			}
+
+			conds = append(conds, fmt.Sprintf("EXISTS (SELECT 1 FROM item_attribute WHERE item_attribute.item_id = items.id AND item_attribute.attribute_id = ? AND %s)", strings.Join(bounds, " AND ")))
+			args = append(args, attribute.ID)
+			args = append(args, boundArgs...)
+		default:
+			return nil, nil, fmt.Errorf("'%s' unsupported operator to filter items", pred.Op)
+		}
+	}
+
+	return conds, args, nil
+}
+
+// QueryItems lists the items of arg.EntityID (or of every entity when 0) matching all of arg.Where
+func (q *Queries) QueryItems(ctx context.Context, arg ItemQuery) ([]Item, error) {
+	conds, args, err := q.whereItems(ctx, arg.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	if arg.EntityID != 0 {
+		conds = append([]string{"items.entity_id = ?"}, conds...)
+		args = append([]any{arg.EntityID}, args...)
+	}
+
+	conds = append([]string{"items.tenant_id = ?"}, conds...)
+	args = append([]any{q.tenant}, args...)
+
+	var sb strings.Builder
+	sb.WriteString("SELECT items.id, items.entity_id FROM items WHERE ")
+	sb.WriteString(strings.Join(conds, " AND "))
+	sb.WriteString(" ORDER BY items.id;")
+
+	rows, err := q.queryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var i Item
+
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntityID,
+		); err != nil {
+			return items, classify(err)
+		}
+
+		items = append(items, i)
+	}
+
+	return items, classify(rows.Err())
+}
@@ -0,0 +1,464 @@
+package geaves
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SchemaVersion is the version of the schema file format, ReadSchema refuses any other version
+const SchemaVersion = 1
+
+// Schema declares every entity and attribute that should exist, entities link to attributes by slug
+type Schema struct {
+	Version int `json:"version"`
+	Attributes []SchemaAttribute `json:"attributes"`
+	Entities []SchemaEntity `json:"entities"`
+}
+
+type SchemaAttribute struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type AttributeType `json:"type"`
+}
+
+type SchemaEntity struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Attributes []SchemaLink `json:"attributes"`
+}
+
+type SchemaLink struct {
+	Slug string `json:"slug"`
+	Required bool `json:"required"`
+}
+
+func ReadSchema(r io.Reader) (Schema, error) {
+	var s Schema
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return s, fmt.Errorf("Failed to read schema: %w", err)
+	}
+
+	return s, s.Validate()
+}
+
+func (s Schema) Validate() error {
+	if s.Version != SchemaVersion {
+		return fmt.Errorf("Unsupported schema version %v, expected %v", s.Version, SchemaVersion)
+	}
+
+	attributes := map[string]bool{}
+	for _, attribute := range s.Attributes {
+		if attribute.Slug == "" || attribute.Name == "" {
+			return fmt.Errorf("Attributes must have both a name and a slug, got '%s' (%s)", attribute.Name, attribute.Slug)
+		}
+
+		if attributes[attribute.Slug] {
+			return fmt.Errorf("Attribute %s is declared more than once", attribute.Slug)
+		}
+
+		if !ValidAttributeType(string(attribute.Type)) {
+			return fmt.Errorf("Attribute %s has invalid type '%s'", attribute.Slug, attribute.Type)
+		}
+
+		attributes[attribute.Slug] = true
+	}
+
+	entities := map[string]bool{}
+	for _, entity := range s.Entities {
+		if entity.Slug == "" || entity.Name == "" {
+			return fmt.Errorf("Entities must have both a name and a slug, got '%s' (%s)", entity.Name, entity.Slug)
+		}
+
+		if entities[entity.Slug] {
+			return fmt.Errorf("Entity %s is declared more than once", entity.Slug)
+		}
+		entities[entity.Slug] = true
+
+		linked := map[string]bool{}
+		for _, link := range entity.Attributes {
+			if !attributes[link.Slug] {
+				return fmt.Errorf("Entity %s links to attribute %s, which is not declared", entity.Slug, link.Slug)
+			}
+
+			if linked[link.Slug] {
+				return fmt.Errorf("Entity %s links to attribute %s more than once", entity.Slug, link.Slug)
+			}
+			linked[link.Slug] = true
+		}
+	}
+
+	return nil
+}
+
+// DumpSchema reads the schema of the database, sorted by slug so dumps of the same schema are identical
+func DumpSchema(ctx context.Context, q *Queries) (Schema, error) {
+	s := Schema{
+		Version: SchemaVersion,
+		Attributes: []SchemaAttribute{},
+		Entities: []SchemaEntity{},
+	}
+
+	attributes, err := q.ListAttributes(ctx, false)
+	if err != nil {
+		return s, fmt.Errorf("Failed to list attributes: %w", err)
+	}
+
+	for _, attribute := range attributes {
+		s.Attributes = append(s.Attributes, SchemaAttribute{attribute.Name, attribute.Slug, attribute.Type})
+	}
+
+	entities, err := q.ListEntities(ctx, true)
+	if err != nil {
+		return s, fmt.Errorf("Failed to list entities: %w", err)
+	}
+
+	for _, entity := range entities {
+		links, err := entity.GetAttributes(ctx, q)
+		if err != nil {
+			return s, fmt.Errorf("Failed to get attributes of %s: %w", entity.Slug, err)
+		}
+
+		dumped := SchemaEntity{entity.Name, entity.Slug, []SchemaLink{}}
+		for _, link := range links {
+			dumped.Attributes = append(dumped.Attributes, SchemaLink{link.Slug, link.Required})
+		}
+
+		slices.SortFunc(dumped.Attributes, func(a, b SchemaLink) int { return strings.Compare(a.Slug, b.Slug) })
+		s.Entities = append(s.Entities, dumped)
+	}
+
+	slices.SortFunc(s.Attributes, func(a, b SchemaAttribute) int { return strings.Compare(a.Slug, b.Slug) })
+	slices.SortFunc(s.Entities, func(a, b SchemaEntity) int { return strings.Compare(a.Slug, b.Slug) })
+
+	return s, nil
+}
+
+func WriteSchema(w io.Writer, s Schema) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+type SchemaChangeKind string
+const (
+	CreateAttributeChange SchemaChangeKind = "create-attribute"
+	RenameAttributeChange SchemaChangeKind = "rename-attribute"
+	RetypeAttributeChange SchemaChangeKind = "retype-attribute"
+	CreateEntityChange SchemaChangeKind = "create-entity"
+	RenameEntityChange SchemaChangeKind = "rename-entity"
+	LinkChange SchemaChangeKind = "link"
+	UnlinkChange SchemaChangeKind = "unlink"
+	RequireChange SchemaChangeKind = "require"
+	OptionalChange SchemaChangeKind = "optional"
+	DropEntityChange SchemaChangeKind = "drop-entity"
+	DropAttributeChange SchemaChangeKind = "drop-attribute"
+)
+
+// SchemaChange is one step of reconciling the database with a schema, Entity and Attribute are slugs,
+// From and To hold the old and new name for renames and the old and new type for retypes, To holds the
+// name for creates and "required" or "optional" for links, Type is the type of created attributes.
+// Destructive changes lose item values
+type SchemaChange struct {
+	Kind SchemaChangeKind
+	Entity string
+	Attribute string
+	From string
+	To string
+	Type AttributeType
+	Destructive bool
+}
+
+func (c SchemaChange) String() string {
+	var s string
+
+	switch c.Kind {
+	case CreateAttributeChange:
+		s = fmt.Sprintf("+ create attribute %s (%s): %s", c.To, c.Attribute, c.Type)
+	case RenameAttributeChange:
+		s = fmt.Sprintf("~ rename attribute %s: %s -> %s", c.Attribute, c.From, c.To)
+	case RetypeAttributeChange:
+		s = fmt.Sprintf("~ change type of attribute %s: %s -> %s, deleting its values", c.Attribute, c.From, c.To)
+	case CreateEntityChange:
+		s = fmt.Sprintf("+ create entity %s (%s)", c.To, c.Entity)
+	case RenameEntityChange:
+		s = fmt.Sprintf("~ rename entity %s: %s -> %s", c.Entity, c.From, c.To)
+	case LinkChange:
+		s = fmt.Sprintf("+ link %s to %s (%s)", c.Attribute, c.Entity, c.To)
+	case UnlinkChange:
+		s = fmt.Sprintf("- unlink %s from %s, deleting its values on items of %s", c.Attribute, c.Entity, c.Entity)
+	case RequireChange:
+		s = fmt.Sprintf("~ make %s required on %s", c.Attribute, c.Entity)
+	case OptionalChange:
+		s = fmt.Sprintf("~ make %s optional on %s", c.Attribute, c.Entity)
+	case DropEntityChange:
+		s = fmt.Sprintf("- drop entity %s, deleting all its items", c.Entity)
+	case DropAttributeChange:
+		s = fmt.Sprintf("- drop attribute %s, deleting all its values", c.Attribute)
+	default:
+		s = fmt.Sprintf("? %s", c.Kind)
+	}
+
+	if c.Destructive {
+		s += " (destructive)"
+	}
+
+	return s
+}
+
+// SchemaPlan lists the changes, in the order they are applied, to make the database match a schema
+type SchemaPlan struct {
+	Changes []SchemaChange
+}
+
+func (p SchemaPlan) Destructive() []SchemaChange {
+	var changes []SchemaChange
+	for _, change := range p.Changes {
+		if change.Destructive {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+func (p SchemaPlan) String() string {
+	var sb strings.Builder
+	for _, change := range p.Changes {
+		sb.WriteString(change.String())
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+var ErrDestructiveChange = errors.New("schema plan has destructive changes")
+
+func PlanSchema(ctx context.Context, q *Queries, s Schema) (SchemaPlan, error) {
+	var plan SchemaPlan
+
+	if err := s.Validate(); err != nil {
+		return plan, err
+	}
+
+	attributes, err := q.ListAttributes(ctx, false)
+	if err != nil {
+		return plan, fmt.Errorf("Failed to list attributes: %w", err)
+	}
+
+	existingAttributes := make(map[string]Attribute, len(attributes))
+	for _, attribute := range attributes {
+		existingAttributes[attribute.Slug] = attribute
+	}
+
+	entities, err := q.ListEntities(ctx, false)
+	if err != nil {
+		return plan, fmt.Errorf("Failed to list entities: %w", err)
+	}
+
+	existingEntities := make(map[string]Entity, len(entities))
+	for _, entity := range entities {
+		existingEntities[entity.Slug] = entity
+	}
+
+	declaredAttributes := map[string]bool{}
+	for _, declared := range s.Attributes {
+		declaredAttributes[declared.Slug] = true
+
+		existing, ok := existingAttributes[declared.Slug]
+		if !ok {
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: CreateAttributeChange, Attribute: declared.Slug, To: declared.Name, Type: declared.Type})
+			continue
+		}
+
+		if existing.Name != declared.Name {
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: RenameAttributeChange, Attribute: declared.Slug, From: existing.Name, To: declared.Name})
+		}
+
+		if existing.Type != declared.Type {
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: RetypeAttributeChange, Attribute: declared.Slug, From: string(existing.Type), To: string(declared.Type), Destructive: true})
+		}
+	}
+
+	declaredEntities := map[string]bool{}
+	for _, declared := range s.Entities {
+		declaredEntities[declared.Slug] = true
+
+		existing, ok := existingEntities[declared.Slug]
+		if !ok {
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: CreateEntityChange, Entity: declared.Slug, To: declared.Name})
+		} else if existing.Name != declared.Name {
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: RenameEntityChange, Entity: declared.Slug, From: existing.Name, To: declared.Name})
+		}
+
+		linked := map[string]bool{}
+		if ok {
+			links, err := existing.GetAttributes(ctx, q)
+			if err != nil {
+				return plan, fmt.Errorf("Failed to get attributes of %s: %w", existing.Slug, err)
+			}
+
+			for _, link := range links {
+				linked[link.Slug] = link.Required
+			}
+		}
+
+		for _, link := range declared.Attributes {
+			required, exists := linked[link.Slug]
+			switch {
+			case !exists:
+				to := "optional"
+				if link.Required {
+					to = "required"
+				}
+				plan.Changes = append(plan.Changes, SchemaChange{Kind: LinkChange, Entity: declared.Slug, Attribute: link.Slug, To: to})
+			case link.Required && !required:
+				plan.Changes = append(plan.Changes, SchemaChange{Kind: RequireChange, Entity: declared.Slug, Attribute: link.Slug})
+			case !link.Required && required:
+				plan.Changes = append(plan.Changes, SchemaChange{Kind: OptionalChange, Entity: declared.Slug, Attribute: link.Slug})
+			}
+
+			delete(linked, link.Slug)
+		}
+
+		// Dropped attributes take their links with them
+		for _, entityAttribute := range slices.Sorted(maps.Keys(linked)) {
+			if declaredAttributes[entityAttribute] {
+				plan.Changes = append(plan.Changes, SchemaChange{Kind: UnlinkChange, Entity: declared.Slug, Attribute: entityAttribute, Destructive: true})
+			}
+		}
+	}
+
+	// Drops go first, so created and renamed entities and attributes can take the names of dropped ones
+	var drops []SchemaChange
+	for _, entity := range entities {
+		if !declaredEntities[entity.Slug] {
+			drops = append(drops, SchemaChange{Kind: DropEntityChange, Entity: entity.Slug, Destructive: true})
+		}
+	}
+
+	for _, attribute := range attributes {
+		if !declaredAttributes[attribute.Slug] {
+			drops = append(drops, SchemaChange{Kind: DropAttributeChange, Attribute: attribute.Slug, Destructive: true})
+		}
+	}
+
+	plan.Changes = append(drops, plan.Changes...)
+	return plan, nil
+}
+
+type ApplySchemaOptions struct {
+	AllowDestructive bool
+}
+
+// ApplySchema plans and applies the changes making the database match s, returning the applied plan.
+// Unless opts.AllowDestructive is set a plan with destructive changes is not applied and ErrDestructiveChange
+// is returned. Planning and applying share one transaction (see RunInTx), so a change failing half way applies none
+func ApplySchema(ctx context.Context, q *Queries, s Schema, opts ApplySchemaOptions) (SchemaPlan, error) {
+	var plan SchemaPlan
+	err := q.RunInTx(ctx, func(q *Queries) error {
+		var err error
+		plan, err = applySchema(ctx, q, s, opts)
+		return err
+	})
+
+	return plan, err
+}
+
+func applySchema(ctx context.Context, q *Queries, s Schema, opts ApplySchemaOptions) (SchemaPlan, error) {
+	plan, err := PlanSchema(ctx, q, s)
+	if err != nil {
+		return plan, err
+	}
+
+	if destructive := plan.Destructive(); len(destructive) > 0 && !opts.AllowDestructive {
+		return plan, fmt.Errorf("%w, refusing to apply:\n%s", ErrDestructiveChange, strings.TrimSuffix(SchemaPlan{destructive}.String(), "\n"))
+	}
+
+	for _, change := range plan.Changes {
+		if err := applySchemaChange(ctx, q, change); err != nil {
+			return plan, fmt.Errorf("Failed to apply '%s': %w", change, err)
+		}
+	}
+
+	return plan, nil
+}
+
+func applySchemaChange(ctx context.Context, q *Queries, change SchemaChange) error {
+	var entity Entity
+	var attribute Attribute
+	var err error
+
+	if change.Entity != "" && change.Kind != CreateEntityChange {
+		entity, err = q.GetEntity(ctx, GetEntityParam{Field: BySlug, Value: change.Entity})
+		if err != nil {
+			return err
+		}
+	}
+
+	if change.Attribute != "" && change.Kind != CreateAttributeChange {
+		attribute, err = q.GetAttribute(ctx, GetAttributeParam{Field: BySlug, Value: change.Attribute})
+		if err != nil {
+			return err
+		}
+	}
+
+	switch change.Kind {
+	case CreateAttributeChange:
+		_, err = q.CreateAttribute(ctx, CreateAttributeParam{change.To, change.Attribute, change.Type})
+		return err
+	case RenameAttributeChange:
+		return q.UpdateAttributeName(ctx, change.To, attribute.ID)
+	case RetypeAttributeChange:
+		if err := q.DeleteItemAttributesByAttribute(ctx, attribute.ID); err != nil {
+			return err
+		}
+		return q.UpdateAttributeType(ctx, AttributeType(change.To), attribute.ID)
+	case CreateEntityChange:
+		_, err = q.CreateEntity(ctx, CreateEntityParam{change.To, change.Entity})
+		return err
+	case RenameEntityChange:
+		return q.UpdateEntityName(ctx, change.To, entity.ID)
+	case LinkChange:
+		_, err = q.CreateEntityAttribute(ctx, EntityAttribute{entity.ID, attribute.ID, change.To == "required"})
+		return err
+	case UnlinkChange:
+		if err := q.DeleteItemAttributesByEntityAttribute(ctx, entity.ID, attribute.ID); err != nil {
+			return err
+		}
+		return q.DeleteEntityAttribute(ctx, entity.ID, attribute.ID)
+	case RequireChange:
+		return q.UpdateRequireEntityAttribute(ctx, true, entity.ID, attribute.ID)
+	case OptionalChange:
+		return q.UpdateRequireEntityAttribute(ctx, false, entity.ID, attribute.ID)
+	case DropEntityChange:
+		if err := q.DeleteItemAttributesByEntity(ctx, entity.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteItemsByEntity(ctx, entity.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteEntityAttributeByEntity(ctx, entity.ID); err != nil {
+			return err
+		}
+		return q.DeleteEntity(ctx, entity.ID)
+	case DropAttributeChange:
+		if err := q.DeleteItemAttributesByAttribute(ctx, attribute.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteEntityAttributeByAttribute(ctx, attribute.ID); err != nil {
+			return err
+		}
+		return q.DeleteAttribute(ctx, attribute.ID)
+	default:
+		return fmt.Errorf("'%s' unsupported schema change", change.Kind)
+	}
+}
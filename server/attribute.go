@@ -0,0 +1,163 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Asfolny/geaves"
+)
+
+type Attribute struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type geaves.AttributeType `json:"type"`
+	Entities []AttributeEntity `json:"entities"`
+}
+
+// AttributeEntity is an entity an attribute is linked to
+type AttributeEntity struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Required bool `json:"required"`
+}
+
+type CreateAttributeRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type geaves.AttributeType `json:"type"`
+}
+
+// UpdateAttributeRequest changes the fields that are set
+type UpdateAttributeRequest struct {
+	Name *string `json:"name,omitempty"`
+	Slug *string `json:"slug,omitempty"`
+	Type *geaves.AttributeType `json:"type,omitempty"`
+}
+
+func toAttribute(r *http.Request, q *geaves.Queries, attribute geaves.Attribute) (Attribute, error) {
+	res := Attribute{ID: attribute.ID, Name: attribute.Name, Slug: attribute.Slug, Type: attribute.Type, Entities: []AttributeEntity{}}
+
+	entities, err := attribute.GetEntities(r.Context(), q)
+	if err != nil {
+		return res, err
+	}
+
+	for _, entity := range entities {
+		res.Entities = append(res.Entities, AttributeEntity{entity.ID, entity.Name, entity.Slug, entity.Required})
+	}
+
+	return res, nil
+}
+
+func listAttributes(r *http.Request, q *geaves.Queries) (int, any, error) {
+	attributes, err := q.ListAttributes(r.Context(), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res := make([]Attribute, len(attributes))
+	for idx, attribute := range attributes {
+		res[idx], err = toAttribute(r, q, attribute)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return http.StatusOK, res, nil
+}
+
+func createAttribute(r *http.Request, q *geaves.Queries) (int, any, error) {
+	var req CreateAttributeRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if req.Name == "" || req.Slug == "" {
+		return 0, nil, badRequest("An attribute needs both a name and a slug")
+	}
+
+	if !geaves.ValidAttributeType(string(req.Type)) {
+		return 0, nil, &geaves.ValidationError{Field: "type", Value: req.Type, Err: geaves.ErrInvalidType}
+	}
+
+	attribute, err := q.CreateAttribute(r.Context(), geaves.CreateAttributeParam{Name: req.Name, Slug: req.Slug, Type: req.Type})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, Attribute{attribute.ID, attribute.Name, attribute.Slug, attribute.Type, []AttributeEntity{}}, nil
+}
+
+func getAttribute(r *http.Request, q *geaves.Queries) (int, any, error) {
+	attribute, err := lookupAttribute(r.Context(), q, r.PathValue("attribute"), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := toAttribute(r, q, attribute)
+	return http.StatusOK, res, err
+}
+
+func updateAttribute(r *http.Request, q *geaves.Queries) (int, any, error) {
+	attribute, err := lookupAttribute(r.Context(), q, r.PathValue("attribute"), false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var req UpdateAttributeRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if req.Name != nil && *req.Name != attribute.Name {
+		if *req.Name == "" {
+			return 0, nil, badRequest("The name of an attribute can not be empty")
+		}
+
+		if err := q.UpdateAttributeName(r.Context(), *req.Name, attribute.ID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if req.Slug != nil && *req.Slug != attribute.Slug {
+		if *req.Slug == "" {
+			return 0, nil, badRequest("The slug of an attribute can not be empty")
+		}
+
+		if err := q.UpdateAttributeSlug(r.Context(), *req.Slug, attribute.ID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if req.Type != nil && *req.Type != attribute.Type {
+		if !geaves.ValidAttributeType(string(*req.Type)) {
+			return 0, nil, &geaves.ValidationError{Field: "type", Value: *req.Type, Err: geaves.ErrInvalidType}
+		}
+
+		if err := q.UpdateAttributeType(r.Context(), *req.Type, attribute.ID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	attribute, err = q.GetAttribute(r.Context(), geaves.GetAttributeParam{WithEntities: true, Field: geaves.ByID, Value: attribute.ID})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := toAttribute(r, q, attribute)
+	return http.StatusOK, res, err
+}
+
+func deleteAttribute(r *http.Request, q *geaves.Queries) (int, any, error) {
+	attribute, err := lookupAttribute(r.Context(), q, r.PathValue("attribute"), false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := q.DeleteAttribute(r.Context(), attribute.ID); err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Asfolny/geaves"
+)
+
+type Entity struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Attributes []EntityAttribute `json:"attributes"`
+}
+
+// EntityAttribute is an attribute as linked to an entity
+type EntityAttribute struct {
+	ID int64 `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Type geaves.AttributeType `json:"type"`
+	Required bool `json:"required"`
+}
+
+type CreateEntityRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// UpdateEntityRequest changes the fields that are set
+type UpdateEntityRequest struct {
+	Name *string `json:"name,omitempty"`
+	Slug *string `json:"slug,omitempty"`
+}
+
+type LinkRequest struct {
+	Required bool `json:"required"`
+}
+
+func toEntity(r *http.Request, q *geaves.Queries, entity geaves.Entity) (Entity, error) {
+	res := Entity{ID: entity.ID, Name: entity.Name, Slug: entity.Slug, Attributes: []EntityAttribute{}}
+
+	attributes, err := entity.GetAttributes(r.Context(), q)
+	if err != nil {
+		return res, err
+	}
+
+	for _, attribute := range attributes {
+		res.Attributes = append(res.Attributes, toEntityAttribute(attribute))
+	}
+
+	return res, nil
+}
+
+func toEntityAttribute(attribute geaves.EntityAttributeEmbed) EntityAttribute {
+	return EntityAttribute{attribute.ID, attribute.Name, attribute.Slug, attribute.Type, attribute.Required}
+}
+
+func listEntities(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entities, err := q.ListEntities(r.Context(), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res := make([]Entity, len(entities))
+	for idx, entity := range entities {
+		res[idx], err = toEntity(r, q, entity)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return http.StatusOK, res, nil
+}
+
+func createEntity(r *http.Request, q *geaves.Queries) (int, any, error) {
+	var req CreateEntityRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if req.Name == "" || req.Slug == "" {
+		return 0, nil, badRequest("An entity needs both a name and a slug")
+	}
+
+	entity, err := q.CreateEntity(r.Context(), geaves.CreateEntityParam{Name: req.Name, Slug: req.Slug})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusCreated, Entity{ID: entity.ID, Name: entity.Name, Slug: entity.Slug, Attributes: []EntityAttribute{}}, nil
+}
+
+func getEntity(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := toEntity(r, q, entity)
+	return http.StatusOK, res, err
+}
+
+func updateEntity(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var req UpdateEntityRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if req.Name != nil && *req.Name != entity.Name {
+		if *req.Name == "" {
+			return 0, nil, badRequest("The name of an entity can not be empty")
+		}
+
+		if err := q.UpdateEntityName(r.Context(), *req.Name, entity.ID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if req.Slug != nil && *req.Slug != entity.Slug {
+		if *req.Slug == "" {
+			return 0, nil, badRequest("The slug of an entity can not be empty")
+		}
+
+		if err := q.UpdateEntitySlug(r.Context(), *req.Slug, entity.ID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	entity, err = q.GetEntity(r.Context(), geaves.GetEntityParam{WithAttributes: true, Field: geaves.ByID, Value: entity.ID})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := toEntity(r, q, entity)
+	return http.StatusOK, res, err
+}
+
+func deleteEntity(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := q.DeleteEntity(r.Context(), entity.ID); err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+func listEntityAttributes(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := toEntity(r, q, entity)
+	return http.StatusOK, res.Attributes, err
+}
+
+// linkEntityAttribute links the attribute to the entity, or changes whether it is required when already linked
+func linkEntityAttribute(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	attribute, err := lookupAttribute(r.Context(), q, r.PathValue("attribute"), false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var req LinkRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	attributes, err := entity.GetAttributes(r.Context(), q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := http.StatusCreated
+	for _, linked := range attributes {
+		if linked.ID == attribute.ID {
+			status = http.StatusOK
+		}
+	}
+
+	if status == http.StatusOK {
+		err = q.UpdateRequireEntityAttribute(r.Context(), req.Required, entity.ID, attribute.ID)
+	} else {
+		_, err = q.CreateEntityAttribute(r.Context(), geaves.EntityAttribute{EntityID: entity.ID, AttributeID: attribute.ID, Required: req.Required})
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return status, toEntityAttribute(geaves.EntityAttributeEmbed{Attribute: attribute, Required: req.Required}), nil
+}
+
+func unlinkEntityAttribute(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	attribute, err := lookupAttribute(r.Context(), q, r.PathValue("attribute"), false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	attributes, err := entity.GetAttributes(r.Context(), q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	linked := false
+	for _, a := range attributes {
+		linked = linked || a.ID == attribute.ID
+	}
+
+	if !linked {
+		return 0, nil, notFound("Attribute %s is not linked to %s", attribute.Slug, entity.Slug)
+	}
+
+	if err := q.DeleteEntityAttribute(r.Context(), entity.ID, attribute.ID); err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
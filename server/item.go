@@ -0,0 +1,309 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/Asfolny/geaves"
+)
+
+// Item holds its values by attribute slug, as written by geaves.Value.MarshalJSON
+type Item struct {
+	ID int64 `json:"id"`
+	EntityID int64 `json:"entity_id"`
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+type ItemValue struct {
+	AttributeID int64 `json:"attribute_id"`
+	Attribute string `json:"attribute"`
+	Type geaves.AttributeType `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// CreateItemRequest creates an item with values by attribute slug, every required attribute of the entity must have one
+type CreateItemRequest struct {
+	Values map[string]json.RawMessage `json:"values"`
+}
+
+type SetValueRequest struct {
+	Value json.RawMessage `json:"value"`
+}
+
+func toItem(item geaves.Item, values []geaves.ItemValue) (Item, error) {
+	res := Item{ID: item.ID, EntityID: item.EntityID, Values: map[string]json.RawMessage{}}
+
+	for _, value := range values {
+		data, err := json.Marshal(value.Value)
+		if err != nil {
+			return res, err
+		}
+
+		res.Values[value.Slug] = data
+	}
+
+	return res, nil
+}
+
+func loadItem(r *http.Request, q *geaves.Queries, item geaves.Item) (Item, error) {
+	values, err := q.ListItemValues(r.Context(), item.ID)
+	if err != nil {
+		return Item{}, err
+	}
+
+	return toItem(item, values)
+}
+
+func listItems(r *http.Request, q *geaves.Queries) (int, any, error) {
+	items, err := q.ListItems(r.Context())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	values, err := q.ListAllItemValues(r.Context())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	byItem := map[int64][]geaves.ItemValue{}
+	for _, value := range values {
+		byItem[value.ItemID] = append(byItem[value.ItemID], value)
+	}
+
+	res := make([]Item, len(items))
+	for idx, item := range items {
+		res[idx], err = toItem(item, byItem[item.ID])
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return http.StatusOK, res, nil
+}
+
+func listEntityItems(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	items, err := q.QueryItems(r.Context(), geaves.ItemQuery{EntityID: entity.ID})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res := make([]Item, len(items))
+	for idx, item := range items {
+		res[idx], err = loadItem(r, q, item)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return http.StatusOK, res, nil
+}
+
+func createItem(r *http.Request, q *geaves.Queries) (int, any, error) {
+	entity, err := lookupEntity(r.Context(), q, r.PathValue("entity"), true)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var req CreateItemRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	attributes, err := entity.GetAttributes(r.Context(), q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	values := make(map[int64]geaves.Value, len(req.Values))
+	for slug, data := range req.Values {
+		idx := slices.IndexFunc(attributes, func(a geaves.EntityAttributeEmbed) bool { return a.Slug == slug })
+		if idx < 0 {
+			return 0, nil, badRequest("Attribute %s is not linked to %s", slug, entity.Slug)
+		}
+
+		value, err := geaves.DecodeJSONValue(attributes[idx].Type, data)
+		if err != nil {
+			return 0, nil, badRequest("Invalid %s: %v", slug, err)
+		}
+
+		if !value.IsNull() {
+			values[attributes[idx].ID] = value
+		}
+	}
+
+	for _, attribute := range attributes {
+		if _, ok := values[attribute.ID]; attribute.Required && !ok {
+			return 0, nil, &geaves.ValidationError{Field: attribute.Slug, Err: geaves.ErrRequiredMissing}
+		}
+	}
+
+	item, err := q.CreateItem(r.Context(), entity.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for attributeID, value := range values {
+		if err := q.SetItemValue(r.Context(), item.ID, attributeID, value); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	res, err := loadItem(r, q, item)
+	return http.StatusCreated, res, err
+}
+
+func getItem(r *http.Request, q *geaves.Queries) (int, any, error) {
+	item, err := lookupItem(r.Context(), q, r.PathValue("item"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res, err := loadItem(r, q, item)
+	return http.StatusOK, res, err
+}
+
+func deleteItem(r *http.Request, q *geaves.Queries) (int, any, error) {
+	item, err := lookupItem(r.Context(), q, r.PathValue("item"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := q.DeleteItemAttributesByItem(r.Context(), item.ID); err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusNoContent, nil, q.DeleteItem(r.Context(), item.ID)
+}
+
+func listItemValues(r *http.Request, q *geaves.Queries) (int, any, error) {
+	item, err := lookupItem(r.Context(), q, r.PathValue("item"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	values, err := q.ListItemValues(r.Context(), item.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	res := make([]ItemValue, len(values))
+	for idx, value := range values {
+		data, err := json.Marshal(value.Value)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		res[idx] = ItemValue{value.AttributeID, value.Slug, value.Type, data}
+	}
+
+	return http.StatusOK, res, nil
+}
+
+// lookupItemAttribute finds the item and one of the attributes linked to its entity
+func lookupItemAttribute(r *http.Request, q *geaves.Queries) (geaves.Item, geaves.EntityAttributeEmbed, error) {
+	item, err := lookupItem(r.Context(), q, r.PathValue("item"))
+	if err != nil {
+		return item, geaves.EntityAttributeEmbed{}, err
+	}
+
+	attribute, err := lookupAttribute(r.Context(), q, r.PathValue("attribute"), false)
+	if err != nil {
+		return item, geaves.EntityAttributeEmbed{}, err
+	}
+
+	attributes, err := q.LoadAttributesByEntity(r.Context(), item.EntityID)
+	if err != nil {
+		return item, geaves.EntityAttributeEmbed{}, err
+	}
+
+	idx := slices.IndexFunc(attributes, func(a geaves.EntityAttributeEmbed) bool { return a.ID == attribute.ID })
+	if idx < 0 {
+		return item, geaves.EntityAttributeEmbed{}, badRequest("Attribute %s is not linked to the entity of item %v", attribute.Slug, item.ID)
+	}
+
+	return item, attributes[idx], nil
+}
+
+func getItemValue(r *http.Request, q *geaves.Queries) (int, any, error) {
+	item, attribute, err := lookupItemAttribute(r, q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	values, err := q.ListItemValues(r.Context(), item.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	idx := slices.IndexFunc(values, func(v geaves.ItemValue) bool { return v.AttributeID == attribute.ID })
+	if idx < 0 {
+		return 0, nil, notFound("Item %v has no value for %s", item.ID, attribute.Slug)
+	}
+
+	data, err := json.Marshal(values[idx].Value)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, ItemValue{attribute.ID, attribute.Slug, attribute.Type, data}, nil
+}
+
+// setItemValue stores the value, setting null removes it unless the attribute is required
+func setItemValue(r *http.Request, q *geaves.Queries) (int, any, error) {
+	item, attribute, err := lookupItemAttribute(r, q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var req SetValueRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if req.Value == nil {
+		req.Value = json.RawMessage("null")
+	}
+
+	value, err := geaves.DecodeJSONValue(attribute.Type, req.Value)
+	if err != nil {
+		return 0, nil, badRequest("Invalid %s: %v", attribute.Slug, err)
+	}
+
+	if value.IsNull() {
+		if attribute.Required {
+			return 0, nil, &geaves.ValidationError{Field: attribute.Slug, Err: geaves.ErrRequiredMissing}
+		}
+
+		return http.StatusNoContent, nil, q.DeleteItemAttributes(r.Context(), item.ID, attribute.ID)
+	}
+
+	if err := q.SetItemValue(r.Context(), item.ID, attribute.ID, value); err != nil {
+		return 0, nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, ItemValue{attribute.ID, attribute.Slug, attribute.Type, data}, nil
+}
+
+func deleteItemValue(r *http.Request, q *geaves.Queries) (int, any, error) {
+	item, attribute, err := lookupItemAttribute(r, q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if attribute.Required {
+		return 0, nil, badRequest("Attribute %s is required", attribute.Slug)
+	}
+
+	return http.StatusNoContent, nil, q.DeleteItemAttributes(r.Context(), item.ID, attribute.ID)
+}
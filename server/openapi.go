@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Asfolny/geaves"
+)
+
+type openAPISchema struct {
+	Ref string `json:"$ref,omitempty"`
+	Type string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+	Minimum *float64 `json:"minimum,omitempty"`
+	Nullable bool `json:"nullable,omitempty"`
+	Properties map[string]*openAPISchema `json:"properties,omitempty"`
+	Required []string `json:"required,omitempty"`
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+	Items *openAPISchema `json:"items,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string `json:"description"`
+	Content map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIRequestBody struct {
+	Required bool `json:"required"`
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+	Summary string `json:"summary"`
+	Tags []string `json:"tags"`
+	RequestBody *openAPIRequestBody `json:"requestBody,omitempty"`
+	Responses map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIDocument struct {
+	OpenAPI string `json:"openapi"`
+	Info struct {
+		Title string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+func schemaRef(name string) *openAPISchema {
+	return &openAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonContent(schema *openAPISchema) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// attributeSchema is the schema of a value of type t, as written by geaves.Value.MarshalJSON
+func attributeSchema(t geaves.AttributeType) *openAPISchema {
+	typ, format := t.JSONType()
+	schema := &openAPISchema{Type: typ, Format: format}
+
+	switch t {
+	case geaves.UintType, geaves.Uint8Type, geaves.Uint16Type, geaves.Uint32Type, geaves.Uint64Type, geaves.ByteType:
+		zero := 0.0
+		schema.Minimum = &zero
+	}
+
+	return schema
+}
+
+// GenerateOpenAPI returns an OpenAPI 3 document describing the item endpoints of every entity, each entity
+// gets a component schema named by its slug with a property per linked attribute, and one named
+// <slug>-item for its items as returned by the API
+func GenerateOpenAPI(ctx context.Context, q *geaves.Queries) ([]byte, error) {
+	entities, err := q.ListEntities(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list entities: %w", err)
+	}
+
+	var doc openAPIDocument
+	doc.OpenAPI = "3.0.3"
+	doc.Info.Title = "geaves"
+	doc.Info.Version = "1"
+	doc.Paths = map[string]map[string]openAPIOperation{}
+	doc.Components.Schemas = map[string]*openAPISchema{
+		"Error": {
+			Type: "object",
+			Properties: map[string]*openAPISchema{"error": {Type: "string"}},
+			Required: []string{"error"},
+		},
+	}
+
+	errorResponse := func(description string) openAPIResponse {
+		return openAPIResponse{Description: description, Content: jsonContent(schemaRef("Error"))}
+	}
+
+	closed := false
+	for _, entity := range entities {
+		attributes, err := entity.GetAttributes(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get attributes of %s: %w", entity.Slug, err)
+		}
+
+		values := &openAPISchema{
+			Type: "object",
+			Description: fmt.Sprintf("Values of an item of %s by attribute slug", entity.Name),
+			Properties: map[string]*openAPISchema{},
+			AdditionalProperties: &closed,
+		}
+
+		for _, attribute := range attributes {
+			schema := attributeSchema(attribute.Type)
+			schema.Description = attribute.Name
+
+			if attribute.Required {
+				values.Required = append(values.Required, attribute.Slug)
+			} else {
+				schema.Nullable = true
+			}
+
+			values.Properties[attribute.Slug] = schema
+		}
+
+		itemName := entity.Slug + "-item"
+		doc.Components.Schemas[entity.Slug] = values
+		doc.Components.Schemas[itemName] = &openAPISchema{
+			Type: "object",
+			Properties: map[string]*openAPISchema{
+				"id": {Type: "integer", Format: "int64"},
+				"entity_id": {Type: "integer", Format: "int64"},
+				"values": schemaRef(entity.Slug),
+			},
+			Required: []string{"id", "entity_id", "values"},
+		}
+
+		doc.Paths[fmt.Sprintf("/entities/%s/items", entity.Slug)] = map[string]openAPIOperation{
+			"get": {
+				OperationID: fmt.Sprintf("list-%s-items", entity.Slug),
+				Summary: fmt.Sprintf("List the items of %s", entity.Name),
+				Tags: []string{entity.Slug},
+				Responses: map[string]openAPIResponse{
+					"200": {
+						Description: fmt.Sprintf("The items of %s", entity.Name),
+						Content: jsonContent(&openAPISchema{Type: "array", Items: schemaRef(itemName)}),
+					},
+				},
+			},
+			"post": {
+				OperationID: fmt.Sprintf("create-%s-item", entity.Slug),
+				Summary: fmt.Sprintf("Create an item of %s", entity.Name),
+				Tags: []string{entity.Slug},
+				RequestBody: &openAPIRequestBody{
+					Required: true,
+					Content: jsonContent(&openAPISchema{
+						Type: "object",
+						Properties: map[string]*openAPISchema{"values": schemaRef(entity.Slug)},
+						Required: []string{"values"},
+					}),
+				},
+				Responses: map[string]openAPIResponse{
+					"201": {Description: "The created item", Content: jsonContent(schemaRef(itemName))},
+					"400": errorResponse("A value is invalid or a required value is missing"),
+					"409": errorResponse("A value breaks a constraint"),
+				},
+			},
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func getOpenAPI(r *http.Request, q *geaves.Queries) (int, any, error) {
+	doc, err := GenerateOpenAPI(r.Context(), q)
+	return http.StatusOK, json.RawMessage(doc), err
+}
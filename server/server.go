@@ -0,0 +1,287 @@
+// Package server exposes a geaves database over HTTP with JSON bodies
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Asfolny/geaves"
+)
+
+// Error is the body of every response with a status of 400 or above, Code names the geaves error it was
+// caused by when there is one, see ErrorForCode
+type Error struct {
+	Error string `json:"error"`
+	Code string `json:"code,omitempty"`
+}
+
+// errorCodes names the geaves errors for the code of an Error, the more specific duplicates first
+var errorCodes = []struct{
+	code string
+	err error
+}{
+	{"not_found", geaves.ErrNotFound},
+	{"duplicate_slug", geaves.ErrDuplicateSlug},
+	{"duplicate_name", geaves.ErrDuplicateName},
+	{"duplicate", geaves.ErrDuplicate},
+	{"invalid_type", geaves.ErrInvalidType},
+	{"required_missing", geaves.ErrRequiredMissing},
+	{"foreign_key", geaves.ErrForeignKey},
+}
+
+func errorCode(err error) string {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	// Lookups of the path fail with their own message, but are not found all the same
+	if errorStatus(err) == http.StatusNotFound {
+		return "not_found"
+	}
+
+	return ""
+}
+
+// ErrorForCode returns the geaves error named by the code of an Error, nil for codes it does not know
+func ErrorForCode(code string) error {
+	for _, c := range errorCodes {
+		if c.code == code {
+			return c.err
+		}
+	}
+
+	return nil
+}
+
+// statusError carries the status a request should fail with, errors without one are mapped by errorStatus
+type statusError struct {
+	status int
+	err error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func badRequest(format string, args ...any) error {
+	return &statusError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func notFound(format string, args ...any) error {
+	return &statusError{http.StatusNotFound, fmt.Errorf(format, args...)}
+}
+
+// sqliteConstraint is the primary result code sqlite fails with when a UNIQUE, CHECK or FOREIGN KEY constraint fails
+const sqliteConstraint = 19
+
+func errorStatus(err error) int {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status
+	}
+
+	var validationErr *geaves.ValidationError
+	switch {
+	case errors.Is(err, geaves.ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, geaves.ErrDuplicate), errors.Is(err, geaves.ErrForeignKey):
+		return http.StatusConflict
+	case errors.As(err, &validationErr), errors.Is(err, geaves.ErrInvalidType), errors.Is(err, geaves.ErrRequiredMissing):
+		return http.StatusBadRequest
+	case geaves.IsBusy(err):
+		return http.StatusServiceUnavailable
+	}
+
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) && coded.Code() & 0xff == sqliteConstraint {
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+// handlerFunc handles a request inside its transaction, the returned body is written as JSON with the
+// returned status once the transaction has been committed, a nil body writes no content
+type handlerFunc func(r *http.Request, q *geaves.Queries) (int, any, error)
+
+type Handler struct {
+	db *sql.DB
+	mux *http.ServeMux
+	// tenant is the id of the tenant every request is scoped to, see geaves.Queries.ForTenant
+	tenant int64
+}
+
+// NewHandler returns the geaves REST API on db, every request runs in its own transaction which is
+// committed when it succeeds and rolled back otherwise
+//
+// Entities and attributes are addressed by id or slug, items by id
+//
+//	GET    /entities
+//	POST   /entities
+//	GET    /entities/{entity}
+//	PATCH  /entities/{entity}
+//	DELETE /entities/{entity}
+//	GET    /entities/{entity}/attributes
+//	PUT    /entities/{entity}/attributes/{attribute}
+//	DELETE /entities/{entity}/attributes/{attribute}
+//	GET    /entities/{entity}/items
+//	POST   /entities/{entity}/items
+//	GET    /attributes
+//	POST   /attributes
+//	GET    /attributes/{attribute}
+//	PATCH  /attributes/{attribute}
+//	DELETE /attributes/{attribute}
+//	GET    /items
+//	GET    /items/{item}
+//	DELETE /items/{item}
+//	GET    /items/{item}/values
+//	GET    /items/{item}/values/{attribute}
+//	PUT    /items/{item}/values/{attribute}
+//	DELETE /items/{item}/values/{attribute}
+//	GET    /openapi.json
+func NewHandler(db *sql.DB) *Handler {
+	return NewTenantHandler(db, geaves.DefaultTenantID)
+}
+
+// NewTenantHandler returns the API of NewHandler scoped to the tenant with id tenant,
+// only its entities, attributes and items can be reached through it
+func NewTenantHandler(db *sql.DB, tenant int64) *Handler {
+	h := &Handler{db: db, mux: http.NewServeMux(), tenant: tenant}
+
+	h.handle("GET /entities", listEntities)
+	h.handle("POST /entities", createEntity)
+	h.handle("GET /entities/{entity}", getEntity)
+	h.handle("PATCH /entities/{entity}", updateEntity)
+	h.handle("DELETE /entities/{entity}", deleteEntity)
+	h.handle("GET /entities/{entity}/attributes", listEntityAttributes)
+	h.handle("PUT /entities/{entity}/attributes/{attribute}", linkEntityAttribute)
+	h.handle("DELETE /entities/{entity}/attributes/{attribute}", unlinkEntityAttribute)
+	h.handle("GET /entities/{entity}/items", listEntityItems)
+	h.handle("POST /entities/{entity}/items", createItem)
+
+	h.handle("GET /attributes", listAttributes)
+	h.handle("POST /attributes", createAttribute)
+	h.handle("GET /attributes/{attribute}", getAttribute)
+	h.handle("PATCH /attributes/{attribute}", updateAttribute)
+	h.handle("DELETE /attributes/{attribute}", deleteAttribute)
+
+	h.handle("GET /items", listItems)
+	h.handle("GET /items/{item}", getItem)
+	h.handle("DELETE /items/{item}", deleteItem)
+	h.handle("GET /items/{item}/values", listItemValues)
+	h.handle("GET /items/{item}/values/{attribute}", getItemValue)
+	h.handle("PUT /items/{item}/values/{attribute}", setItemValue)
+	h.handle("DELETE /items/{item}/values/{attribute}", deleteItemValue)
+
+	h.handle("GET /openapi.json", getOpenAPI)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handle(pattern string, fn handlerFunc) {
+	h.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		status, body, err := h.runInTx(r, fn)
+		if err != nil {
+			writeJSON(w, errorStatus(err), Error{err.Error(), errorCode(err)})
+			return
+		}
+
+		writeJSON(w, status, body)
+	})
+}
+
+// runInTx runs fn with geaves.RunInTx, retrying while the database is busy, GET requests run read only
+func (h *Handler) runInTx(r *http.Request, fn handlerFunc) (int, any, error) {
+	opts := &geaves.TxOptions{ReadOnly: r.Method == http.MethodGet, Tenant: h.tenant}
+
+	var status int
+	var body any
+	err := geaves.RunInTx(r.Context(), h.db, opts, func(q *geaves.Queries) error {
+		var err error
+		status, body, err = fn(r, q)
+		return err
+	})
+
+	return status, body, err
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func readJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return badRequest("Invalid request body: %v", err)
+	}
+
+	return nil
+}
+
+// lookupEntity finds an entity by id when search is a number and by slug otherwise
+func lookupEntity(ctx context.Context, q *geaves.Queries, search string, withAttributes bool) (geaves.Entity, error) {
+	arg := geaves.GetEntityParam{WithAttributes: withAttributes, Field: geaves.BySlug, Value: search}
+	if id, err := strconv.ParseInt(search, 10, 64); err == nil {
+		arg.Field, arg.Value = geaves.ByID, id
+	}
+
+	entity, err := q.GetEntity(ctx, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity, notFound("Entity %s does not exist", search)
+	}
+
+	return entity, err
+}
+
+// lookupAttribute finds an attribute by id when search is a number and by slug otherwise
+func lookupAttribute(ctx context.Context, q *geaves.Queries, search string, withEntities bool) (geaves.Attribute, error) {
+	arg := geaves.GetAttributeParam{WithEntities: withEntities, Field: geaves.BySlug, Value: search}
+	if id, err := strconv.ParseInt(search, 10, 64); err == nil {
+		arg.Field, arg.Value = geaves.ByID, id
+	}
+
+	attribute, err := q.GetAttribute(ctx, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return attribute, notFound("Attribute %s does not exist", search)
+	}
+
+	return attribute, err
+}
+
+func lookupItem(ctx context.Context, q *geaves.Queries, search string) (geaves.Item, error) {
+	id, err := strconv.ParseInt(search, 10, 64)
+	if err != nil {
+		return geaves.Item{}, badRequest("Invalid item id %s", search)
+	}
+
+	item, err := q.GetItem(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return item, notFound("Item %v does not exist", id)
+	}
+
+	return item, err
+}
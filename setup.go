@@ -10,6 +10,9 @@ var tableDefs string
 //go:embed sql/reset.sql
 var resetStmts string
 
+//go:embed sql/tenants.sql
+var tenantStmts string
+
 func SetupSQL() string {
 	return tableDefs
 }
@@ -17,3 +20,9 @@ func SetupSQL() string {
 func ResetSQL() string {
 	return resetStmts
 }
+
+// TenantsSQL upgrades a database set up before tenants, everything in it is kept in the default tenant.
+// It turns foreign keys off while entities and attributes are copied, so it is run as a script like with sqlite3
+func TenantsSQL() string {
+	return tenantStmts
+}
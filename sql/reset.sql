@@ -3,3 +3,4 @@ DROP TABLE attributes;
 DROP TABLE entity_attribute;
 DROP TABLE items;
 DROP TABLE item_attribute;
+DROP TABLE tenants;
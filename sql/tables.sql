@@ -1,15 +1,31 @@
-CREATE TABLE entities (
+CREATE TABLE tenants (
     id INTEGER NOT NULL PRIMARY KEY,
     name STRING NOT NULL UNIQUE,
     slug STRING NOT NULL UNIQUE
 );
 
+INSERT INTO tenants (id, name, slug) VALUES (0, 'Default', 'default');
+
+CREATE TABLE entities (
+    id INTEGER NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0 REFERENCES tenants(id) ON DELETE CASCADE,
+    name STRING NOT NULL,
+    slug STRING NOT NULL,
+
+    UNIQUE (tenant_id, name),
+    UNIQUE (tenant_id, slug)
+);
+
 CREATE TABLE attributes (
     id INTEGER NOT NULL PRIMARY KEY,
-    name STRING NOT NULL UNIQUE,
-    slug STRING NOT NULL UNIQUE,
+    tenant_id INTEGER NOT NULL DEFAULT 0 REFERENCES tenants(id) ON DELETE CASCADE,
+    name STRING NOT NULL,
+    slug STRING NOT NULL,
     type STRING NOT NULL,
 
+    UNIQUE (tenant_id, name),
+    UNIQUE (tenant_id, slug),
+
     CHECK (type IN (
         'bool',
         'string',
@@ -31,6 +47,15 @@ CREATE TABLE attributes (
         'date',
         'time',
         'datetime'
+    ) OR (
+        -- decimal(p,s) and money(p,s) written like NewDecimalType, 1 <= p <= 18 and 0 <= s <= p
+        (type GLOB 'decimal(*)' OR type GLOB 'money(*)')
+        AND (
+            substr(type, instr(type, '(')) GLOB '([1-9],[0-9])'
+            OR substr(type, instr(type, '(')) GLOB '(1[0-8],[0-9])'
+            OR substr(type, instr(type, '(')) GLOB '(1[0-8],1[0-8])'
+        )
+        AND CAST(substr(type, instr(type, ',') + 1) AS INTEGER) <= CAST(substr(type, instr(type, '(') + 1) AS INTEGER)
     ))
 );
 
@@ -44,6 +69,7 @@ CREATE TABLE entity_attribute (
 
 CREATE TABLE items (
     id INTEGER NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0 REFERENCES tenants(id) ON DELETE CASCADE,
     entity_id INTEGER NOT NULL REFERENCES entities(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 
@@ -54,3 +80,28 @@ CREATE TABLE item_attribute (
 
     PRIMARY KEY (item_id, attribute_id)
 );
+
+-- Entities, attributes and items may only be linked within their tenant
+CREATE TRIGGER entity_attribute_tenant_insert BEFORE INSERT ON entity_attribute
+WHEN (SELECT tenant_id FROM entities WHERE id = NEW.entity_id) != (SELECT tenant_id FROM attributes WHERE id = NEW.attribute_id)
+BEGIN SELECT RAISE(ABORT, 'entity and attribute belong to different tenants'); END;
+
+CREATE TRIGGER entity_attribute_tenant_update BEFORE UPDATE OF entity_id, attribute_id ON entity_attribute
+WHEN (SELECT tenant_id FROM entities WHERE id = NEW.entity_id) != (SELECT tenant_id FROM attributes WHERE id = NEW.attribute_id)
+BEGIN SELECT RAISE(ABORT, 'entity and attribute belong to different tenants'); END;
+
+CREATE TRIGGER items_tenant_insert BEFORE INSERT ON items
+WHEN (SELECT tenant_id FROM entities WHERE id = NEW.entity_id) != NEW.tenant_id
+BEGIN SELECT RAISE(ABORT, 'item and entity belong to different tenants'); END;
+
+CREATE TRIGGER items_tenant_update BEFORE UPDATE OF tenant_id, entity_id ON items
+WHEN (SELECT tenant_id FROM entities WHERE id = NEW.entity_id) != NEW.tenant_id
+BEGIN SELECT RAISE(ABORT, 'item and entity belong to different tenants'); END;
+
+CREATE TRIGGER item_attribute_tenant_insert BEFORE INSERT ON item_attribute
+WHEN (SELECT tenant_id FROM items WHERE id = NEW.item_id) != (SELECT tenant_id FROM attributes WHERE id = NEW.attribute_id)
+BEGIN SELECT RAISE(ABORT, 'item and attribute belong to different tenants'); END;
+
+CREATE TRIGGER item_attribute_tenant_update BEFORE UPDATE OF item_id, attribute_id ON item_attribute
+WHEN (SELECT tenant_id FROM items WHERE id = NEW.item_id) != (SELECT tenant_id FROM attributes WHERE id = NEW.attribute_id)
+BEGIN SELECT RAISE(ABORT, 'item and attribute belong to different tenants'); END;
@@ -0,0 +1,182 @@
+package geaves
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultTenantID is the tenant every database starts with, Queries made by New and Prepare are scoped to it
+const DefaultTenantID int64 = 0
+
+// Tenant owns entities, attributes and items of its own, their names and slugs only need to be unique within it.
+// Tenants themselves are managed by Queries of any tenant
+type Tenant struct {
+	ID int64
+	Name string
+	Slug string
+}
+
+// ForTenant returns Queries on the same database, transaction and prepared statements as q scoped to the tenant id,
+// every query they run only finds, lists and changes the entities, attributes and items of that tenant
+func (q *Queries) ForTenant(id int64) *Queries {
+	return &Queries{
+		db: q.db,
+		savepoints: q.savepoints,
+		tx: q.tx,
+		stmts: q.stmts,
+		connStmts: q.connStmts,
+		tenant: id,
+	}
+}
+
+// TenantID is the id of the tenant q is scoped to
+func (q *Queries) TenantID() int64 {
+	return q.tenant
+}
+
+const createTenant = `
+INSERT INTO tenants (name, slug) VALUES (?, ?)
+RETURNING id, name, slug;
+`
+
+type CreateTenantParam struct {
+	Name string
+	Slug string
+}
+
+func (q *Queries) CreateTenant(ctx context.Context, arg CreateTenantParam) (Tenant, error) {
+	row := q.queryRowContext(ctx, createTenant,
+		arg.Name,
+		arg.Slug,
+	)
+
+	var i Tenant
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+	)
+
+	return i, classify(err)
+}
+
+const updateTenantName = `
+UPDATE tenants SET name = ? WHERE id = ?;
+`
+
+func (q *Queries) UpdateTenantName(ctx context.Context, name string, id int64) error {
+	return q.execOne(ctx, updateTenantName, name, id)
+}
+
+const updateTenantSlug = `
+UPDATE tenants SET slug = ? WHERE id = ?;
+`
+
+func (q *Queries) UpdateTenantSlug(ctx context.Context, slug string, id int64) error {
+	return q.execOne(ctx, updateTenantSlug, slug, id)
+}
+
+const deleteTenant = `
+DELETE FROM tenants WHERE id = ?;
+`
+
+// DeleteTenant deletes a tenant along with its entities, attributes, items and entity views,
+// the default tenant can not be deleted
+func (q *Queries) DeleteTenant(ctx context.Context, id int64) error {
+	if id == DefaultTenantID {
+		return &ValidationError{Field: "tenant", Value: id, Err: errors.New("the default tenant can not be deleted")}
+	}
+
+	return q.RunInTx(ctx, func(q *Queries) error {
+		tq := q.ForTenant(id)
+		views, err := tq.ListEntityViews(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to list entity views: %w", err)
+		}
+
+		for _, view := range views {
+			if _, err := q.execContext(ctx, fmt.Sprintf("DROP VIEW %s;", quoteIdent(view))); err != nil {
+				return fmt.Errorf("Failed to drop view %s: %w", view, err)
+			}
+		}
+
+		// Entities, attributes and items are deleted by the cascade of their tenant_id
+		return q.execOne(ctx, deleteTenant, id)
+	})
+}
+
+const getTenantNoFilter = `
+SELECT id, name, slug FROM tenants WHERE %s = ?;
+`
+
+// Variants of the get tenant query by GetType, distinct statements so each can be prepared
+var (
+	getTenantByID = fmt.Sprintf(getTenantNoFilter, "id")
+	getTenantBySlug = fmt.Sprintf(getTenantNoFilter, "slug")
+)
+
+type GetTenantParam struct {
+	Field GetType
+	Value any
+}
+
+func (q *Queries) GetTenant(ctx context.Context, arg GetTenantParam) (Tenant, error) {
+	var query string
+	switch arg.Field {
+	case ByID:
+		if _, ok := arg.Value.(int64); !ok {
+			return Tenant{}, &ValidationError{Field: "GetTenantParam.Value", Value: arg.Value, Err: ErrInvalidType}
+		}
+		query = getTenantByID
+		break;
+	case BySlug:
+		if _, ok := arg.Value.(string); !ok {
+			return Tenant{}, &ValidationError{Field: "GetTenantParam.Value", Value: arg.Value, Err: ErrInvalidType}
+		}
+		query = getTenantBySlug
+		break;
+	default:
+		return Tenant{}, &ValidationError{Field: "GetTenantParam.Field", Value: arg.Field, Err: ErrInvalidType}
+	}
+
+	row := q.queryRowContext(ctx, query, arg.Value)
+
+	var i Tenant
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+	)
+
+	return i, classify(err)
+}
+
+const listTenants = `
+SELECT id, name, slug FROM tenants ORDER BY id;
+`
+
+func (q *Queries) ListTenants(ctx context.Context) ([]Tenant, error) {
+	rows, err := q.queryContext(ctx, listTenants)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer rows.Close()
+
+	var items []Tenant
+	for rows.Next() {
+		var i Tenant
+
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Slug,
+		); err != nil {
+			return items, classify(err)
+		}
+
+		items = append(items, i)
+	}
+
+	return items, classify(rows.Err())
+}
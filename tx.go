@@ -0,0 +1,207 @@
+package geaves
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// sqlite primary result codes RunInTx retries on, another connection holds the lock it needs
+const (
+	sqliteBusy = 5
+	sqliteLocked = 6
+)
+
+// TxOptions configures RunInTx, a nil *TxOptions uses the defaults
+type TxOptions struct {
+	// ReadOnly begins a deferred transaction, which takes no write lock and lets other writers go first,
+	// transactions that may write begin immediate so they can not fail to upgrade their lock half way
+	ReadOnly bool
+	// MaxRetries is how many times a busy or locked database is retried, defaults to 5, negative never retries
+	MaxRetries int
+	// Backoff is the wait before the first retry, doubled for every following retry, defaults to 10ms
+	Backoff time.Duration
+	// Tenant is the tenant the Queries given to fn are scoped to (see ForTenant), defaults to DefaultTenantID
+	Tenant int64
+	// stmts are the statements of the Queries starting the transaction (see Prepare), prepared again on its connection
+	stmts map[string]*sql.Stmt
+}
+
+const (
+	defaultMaxRetries = 5
+	defaultBackoff = 10 * time.Millisecond
+)
+
+// IsBusy reports whether err is sqlite failing with SQLITE_BUSY or SQLITE_LOCKED, which succeed once retried
+func IsBusy(err error) bool {
+	var coded interface{ Code() int }
+	if !errors.As(err, &coded) {
+		return false
+	}
+
+	code := coded.Code() & 0xff
+	return code == sqliteBusy || code == sqliteLocked
+}
+
+// RunInTx runs fn in a transaction on one connection of db, committed when fn returns nil and rolled back
+// when it returns an error or panics, the error of fn is returned as is
+//
+// When the database is busy or locked the whole transaction is rolled back and fn runs again after a backoff,
+// fn should therefore keep its side effects to q until it returns
+//
+// Calling q.RunInTx within fn nests a savepoint in the transaction
+func RunInTx(ctx context.Context, db *sql.DB, opts *TxOptions, fn func(q *Queries) error) error {
+	return retryTx(ctx, opts, func(o TxOptions) error {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed to get a connection: %w", err)
+		}
+		defer conn.Close()
+
+		return runInTx(ctx, conn, o, fn)
+	})
+}
+
+// RunInConn runs fn in a transaction on conn like RunInTx does on a connection of db, for callers holding on to
+// one connection, conn must not be in a transaction already
+func RunInConn(ctx context.Context, conn *sql.Conn, opts *TxOptions, fn func(q *Queries) error) error {
+	return retryTx(ctx, opts, func(o TxOptions) error {
+		return runInTx(ctx, conn, o, fn)
+	})
+}
+
+// retryTx runs attempt with the defaults of opts filled in, again after a backoff while the database is busy or locked
+func retryTx(ctx context.Context, opts *TxOptions, attempt func(o TxOptions) error) error {
+	var o TxOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.MaxRetries == 0 {
+		o.MaxRetries = defaultMaxRetries
+	}
+
+	if o.Backoff <= 0 {
+		o.Backoff = defaultBackoff
+	}
+
+	backoff := o.Backoff
+	for retry := 0; ; retry++ {
+		err := attempt(o)
+		if err == nil || !IsBusy(err) || retry >= o.MaxRetries {
+			return err
+		}
+
+		// Jitter keeps connections that were busy at the same time from retrying at the same time
+		wait := backoff / 2 + rand.N(backoff / 2 + 1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+	}
+}
+
+func runInTx(ctx context.Context, conn *sql.Conn, o TxOptions, fn func(q *Queries) error) error {
+	var connStmts map[string]*sql.Stmt
+	if o.stmts != nil {
+		connStmts = make(map[string]*sql.Stmt, len(o.stmts))
+		// Closed before the connection is used for anything else or goes back to the pool
+		defer func() {
+			for _, stmt := range connStmts {
+				stmt.Close()
+			}
+		}()
+	}
+
+	begin := "BEGIN IMMEDIATE"
+	if o.ReadOnly {
+		begin = "BEGIN DEFERRED"
+	}
+
+	if _, err := conn.ExecContext(ctx, begin); err != nil {
+		return fmt.Errorf("Failed to start a transaction: %w", err)
+	}
+
+	// Rolling back must not be stopped by the cancelled context that may have caused it
+	rollback := func() error {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&Queries{db: conn, stmts: o.stmts, connStmts: connStmts, tenant: o.Tenant}); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			return fmt.Errorf("%w\nAlso failed rollback: %s", err, rbErr)
+		}
+
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		// A failed commit may leave the transaction open, which would follow the connection back into the pool
+		rollback()
+		return fmt.Errorf("Failed to commit: %w", err)
+	}
+
+	return nil
+}
+
+// RunInTx runs fn in a savepoint when q is bound to a transaction, released when fn returns nil and rolled
+// back to when it returns an error or panics, leaving the transaction itself open
+//
+// When q is bound to a *sql.DB it starts a transaction with the defaults of RunInTx instead, scoped to the tenant
+// of q and running the statements q prepared (see Prepare)
+func (q *Queries) RunInTx(ctx context.Context, fn func(q *Queries) error) error {
+	if db, ok := q.db.(*sql.DB); ok {
+		return RunInTx(ctx, db, &TxOptions{Tenant: q.tenant, stmts: q.stmts}, fn)
+	}
+
+	name := fmt.Sprintf("geaves_%d", q.savepoints + 1)
+	if _, err := q.db.ExecContext(ctx, "SAVEPOINT " + name); err != nil {
+		return fmt.Errorf("Failed to create savepoint: %w", err)
+	}
+
+	// Rolling back to a savepoint leaves it open, it is released either way
+	rollback := func() error {
+		ctx := context.WithoutCancel(ctx)
+		if _, err := q.db.ExecContext(ctx, "ROLLBACK TO " + name); err != nil {
+			return err
+		}
+
+		_, err := q.db.ExecContext(ctx, "RELEASE " + name)
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&Queries{db: q.db, savepoints: q.savepoints + 1, tx: q.tx, stmts: q.stmts, connStmts: q.connStmts, tenant: q.tenant}); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			return fmt.Errorf("%w\nAlso failed rollback: %s", err, rbErr)
+		}
+
+		return err
+	}
+
+	if _, err := q.db.ExecContext(ctx, "RELEASE " + name); err != nil {
+		return fmt.Errorf("Failed to release savepoint: %w", err)
+	}
+
+	return nil
+}
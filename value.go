@@ -0,0 +1,833 @@
+package geaves
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value is a single item attribute value paired with the type of the attribute it belongs to,
+// Data holds the Go type named by Type (int8 for int8, []byte for blob, time.Time for date, time and datetime,
+// Decimal for decimal and Money for money), or nil when no value is stored
+type Value struct {
+	Type AttributeType
+	Data any
+}
+
+func (v Value) IsNull() bool {
+	return v.Data == nil
+}
+
+// Layouts date, time and datetime values are stored in, all sort as text in the order of the values they hold
+// so sqlite can compare them, and are read by the date and time functions of sqlite.
+// A datetime is an instant and stored in UTC, a date is the calendar date and a time the clock time of the
+// location they are given in, all three are read back in UTC
+const (
+	storedDateLayout = "2006-01-02"
+	storedTimeLayout = "15:04:05.000000000"
+	storedDatetimeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// Layout the sqlite driver writes time.Time values in, which is how they were stored before the layouts above,
+// still read so values not yet rewritten by MigrateValues can be loaded
+const legacyTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// encodeTime returns tm in the stored layout of type t
+func encodeTime(t AttributeType, tm time.Time) string {
+	switch t {
+	case DateType:
+		return tm.Format(storedDateLayout)
+	case TimeType:
+		return tm.Format(storedTimeLayout)
+	default:
+		return tm.UTC().Format(storedDatetimeLayout)
+	}
+}
+
+// decodeTime reads s as stored for type t, in the stored layout or the legacy one,
+// the RFC 3339 layouts read the stored ones as well as values with fewer fraction digits or another offset
+func decodeTime(t AttributeType, s string) (time.Time, error) {
+	layout := datetimeLayout
+	switch t {
+	case DateType:
+		layout = dateLayout
+	case TimeType:
+		layout = timeLayout
+	}
+
+	tm, err := time.Parse(layout, s)
+	if err != nil {
+		var legacyErr error
+		tm, legacyErr = time.Parse(legacyTimeLayout, s)
+		if legacyErr != nil {
+			return tm, err
+		}
+	}
+
+	return canonicalTime(t, tm), nil
+}
+
+// canonicalTime returns tm as it reads back once stored for type t
+func canonicalTime(t AttributeType, tm time.Time) time.Time {
+	switch t {
+	case DateType:
+		return time.Date(tm.Year(), tm.Month(), tm.Day(), 0, 0, 0, 0, time.UTC)
+	case TimeType:
+		return time.Date(0, time.January, 1, tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), time.UTC)
+	default:
+		return tm.UTC()
+	}
+}
+
+// IsNumeric reports whether sum and avg make sense for values of type t
+func (t AttributeType) IsNumeric() bool {
+	switch t.Base() {
+	case IntType, Int8Type, Int16Type, Int32Type, Int64Type,
+		UintType, Uint8Type, Uint16Type, Uint32Type, Uint64Type,
+		ByteType, RuneType, Float32Type, Float64Type, DecimalType, MoneyType:
+		return true
+	default:
+		return false
+	}
+}
+
+// JSONType returns the json schema type and format (empty when there is none) values of type t
+// are written as by Value.MarshalJSON
+func (t AttributeType) JSONType() (string, string) {
+	switch t.Base() {
+	case BoolType:
+		return "boolean", ""
+	case Int8Type, Int16Type, Int32Type, Uint8Type, Uint16Type, ByteType, RuneType:
+		return "integer", "int32"
+	case IntType, Int64Type, Uint32Type:
+		return "integer", "int64"
+	case UintType, Uint64Type:
+		// Above the range of int64
+		return "integer", ""
+	case Float32Type:
+		return "number", "float"
+	case Float64Type:
+		return "number", "double"
+	case BlobType:
+		return "string", "byte"
+	case DateType:
+		return "string", "date"
+	case TimeType:
+		return "string", "time"
+	case DatetimeType:
+		return "string", "date-time"
+	case DecimalType:
+		// Strings keep every digit, json numbers are read as floats by most
+		return "string", "decimal"
+	case MoneyType:
+		return "string", "money"
+	default:
+		return "string", ""
+	}
+}
+
+// Layouts values of the temporal types are written in outside the database, all are RFC 3339 (full-date, partial-time and date-time)
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04:05.999999999"
+	datetimeLayout = time.RFC3339Nano
+)
+
+// MarshalJSON writes v as the plain json value of its Data, blobs are base64 encoded,
+// date, time and datetime are written as RFC 3339 strings and decimal and money as strings of FormatValue
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch data := v.Data.(type) {
+	case nil:
+		return []byte("null"), nil
+	case time.Time:
+		switch v.Type {
+		case DateType:
+			return json.Marshal(data.Format(dateLayout))
+		case TimeType:
+			return json.Marshal(data.Format(timeLayout))
+		default:
+			return json.Marshal(data.Format(datetimeLayout))
+		}
+	case float32:
+		// Format with 32 bits so 1.2 does not come out as 1.2000000476837158
+		return []byte(strconv.FormatFloat(float64(data), 'g', -1, 32)), nil
+	case Decimal:
+		return json.Marshal(data.String())
+	case Money:
+		return json.Marshal(data.String())
+	default:
+		return json.Marshal(data)
+	}
+}
+
+// DecodeJSONValue reads a json value as written by Value.MarshalJSON into a Value of type t
+func DecodeJSONValue(t AttributeType, data []byte) (Value, error) {
+	v := Value{Type: t}
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return v, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return v, err
+	}
+
+	if base := t.Base(); base == DecimalType || base == MoneyType {
+		switch text := raw.(type) {
+		case string:
+			return ParseValue(t, text)
+		case json.Number:
+			if base == DecimalType {
+				return ParseValue(t, text.String())
+			}
+		}
+
+		return v, fmt.Errorf("%s expects a json string, got %s", t, data)
+	}
+
+	switch t {
+	case BoolType:
+		b, ok := raw.(bool)
+		if !ok {
+			return v, fmt.Errorf("%s expects a json boolean, got %s", t, data)
+		}
+		v.Data = b
+	case StringType, BlobType, DateType, TimeType, DatetimeType:
+		s, ok := raw.(string)
+		if !ok {
+			return v, fmt.Errorf("%s expects a json string, got %s", t, data)
+		}
+
+		switch t {
+		case StringType:
+			v.Data = s
+		case BlobType:
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return v, fmt.Errorf("%s expects base64: %w", t, err)
+			}
+			v.Data = b
+		case DateType:
+			parsed, err := time.Parse(dateLayout, s)
+			if err != nil {
+				return v, err
+			}
+			v.Data = parsed
+		case TimeType:
+			parsed, err := time.Parse(timeLayout, s)
+			if err != nil {
+				return v, err
+			}
+			v.Data = parsed
+		case DatetimeType:
+			parsed, err := time.Parse(datetimeLayout, s)
+			if err != nil {
+				return v, err
+			}
+			v.Data = parsed
+		}
+	default:
+		num, ok := raw.(json.Number)
+		if !ok {
+			return v, fmt.Errorf("%s expects a json number, got %s", t, data)
+		}
+
+		var err error
+		switch t {
+		case IntType:
+			var i int64
+			i, err = strconv.ParseInt(num.String(), 10, 0)
+			v.Data = int(i)
+		case Int8Type:
+			var i int64
+			i, err = strconv.ParseInt(num.String(), 10, 8)
+			v.Data = int8(i)
+		case Int16Type:
+			var i int64
+			i, err = strconv.ParseInt(num.String(), 10, 16)
+			v.Data = int16(i)
+		case Int32Type:
+			var i int64
+			i, err = strconv.ParseInt(num.String(), 10, 32)
+			v.Data = int32(i)
+		case Int64Type:
+			v.Data, err = strconv.ParseInt(num.String(), 10, 64)
+		case RuneType:
+			var i int64
+			i, err = strconv.ParseInt(num.String(), 10, 32)
+			v.Data = rune(i)
+		case UintType:
+			var i uint64
+			i, err = strconv.ParseUint(num.String(), 10, 0)
+			v.Data = uint(i)
+		case Uint8Type, ByteType:
+			var i uint64
+			i, err = strconv.ParseUint(num.String(), 10, 8)
+			v.Data = uint8(i)
+		case Uint16Type:
+			var i uint64
+			i, err = strconv.ParseUint(num.String(), 10, 16)
+			v.Data = uint16(i)
+		case Uint32Type:
+			var i uint64
+			i, err = strconv.ParseUint(num.String(), 10, 32)
+			v.Data = uint32(i)
+		case Uint64Type:
+			v.Data, err = strconv.ParseUint(num.String(), 10, 64)
+		case Float32Type:
+			var f float64
+			f, err = strconv.ParseFloat(num.String(), 32)
+			v.Data = float32(f)
+		case Float64Type:
+			v.Data, err = strconv.ParseFloat(num.String(), 64)
+		default:
+			return v, fmt.Errorf("'%s' is not a valid attribute type", t)
+		}
+
+		if err != nil {
+			v.Data = nil
+			return v, fmt.Errorf("Invalid %s: %w", t, err)
+		}
+	}
+
+	return v, nil
+}
+
+// ParseValue reads s as a value of type t, numbers are written in base 10 with a '.' for decimals,
+// byte and rune take exactly one character, blobs are base64, date, time and datetime are
+// RFC 3339 (datetime also takes "2006-01-02 15:04:05", read as UTC) and money is a decimal
+// followed by its currency code, like 12.30 EUR
+func ParseValue(t AttributeType, s string) (Value, error) {
+	v := Value{Type: t}
+	var err error
+
+	switch t.Base() {
+	case BoolType:
+		v.Data, err = strconv.ParseBool(s)
+	case StringType:
+		v.Data = s
+	case IntType:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 0)
+		v.Data = int(i)
+	case Int8Type:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 8)
+		v.Data = int8(i)
+	case Int16Type:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 16)
+		v.Data = int16(i)
+	case Int32Type:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, 32)
+		v.Data = int32(i)
+	case Int64Type:
+		v.Data, err = strconv.ParseInt(s, 10, 64)
+	case UintType:
+		var i uint64
+		i, err = strconv.ParseUint(s, 10, 0)
+		v.Data = uint(i)
+	case Uint8Type:
+		var i uint64
+		i, err = strconv.ParseUint(s, 10, 8)
+		v.Data = uint8(i)
+	case Uint16Type:
+		var i uint64
+		i, err = strconv.ParseUint(s, 10, 16)
+		v.Data = uint16(i)
+	case Uint32Type:
+		var i uint64
+		i, err = strconv.ParseUint(s, 10, 32)
+		v.Data = uint32(i)
+	case Uint64Type:
+		v.Data, err = strconv.ParseUint(s, 10, 64)
+	case ByteType:
+		if len(s) != 1 {
+			err = fmt.Errorf("'%s' is not exactly one byte", s)
+			break
+		}
+		v.Data = s[0]
+	case RuneType:
+		runes := []rune(s)
+		if len(runes) != 1 {
+			err = fmt.Errorf("'%s' is not exactly one character", s)
+			break
+		}
+		v.Data = runes[0]
+	case Float32Type:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		v.Data = float32(f)
+	case Float64Type:
+		v.Data, err = strconv.ParseFloat(s, 64)
+	case BlobType:
+		v.Data, err = base64.StdEncoding.DecodeString(s)
+	case DateType:
+		v.Data, err = time.Parse(dateLayout, s)
+	case TimeType:
+		v.Data, err = time.Parse(timeLayout, s)
+	case DatetimeType:
+		v.Data, err = time.Parse(datetimeLayout, s)
+		if err != nil {
+			var fallbackErr error
+			v.Data, fallbackErr = time.Parse("2006-01-02 15:04:05", s)
+			if fallbackErr == nil {
+				err = nil
+			}
+		}
+	case DecimalType:
+		var d Decimal
+		d, err = ParseDecimal(s)
+		if err == nil {
+			v.Data, err = scaleDecimal(t, d)
+		}
+	case MoneyType:
+		var m Money
+		m, err = ParseMoney(s)
+		if err == nil {
+			m.Amount, err = scaleDecimal(t, m.Amount)
+			v.Data = m
+		}
+	default:
+		return v, fmt.Errorf("'%s' is not a valid attribute type", t)
+	}
+
+	if err != nil {
+		v.Data = nil
+		return v, fmt.Errorf("Invalid %s: %w", t, err)
+	}
+
+	return v, nil
+}
+
+// FormatValue writes v in the format read by ParseValue, so FormatValue and ParseValue round-trip,
+// a value without Data is written as an empty string
+func FormatValue(v Value) string {
+	switch data := v.Data.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(data)
+	case string:
+		return data
+	case int:
+		return strconv.FormatInt(int64(data), 10)
+	case int8:
+		return strconv.FormatInt(int64(data), 10)
+	case int16:
+		return strconv.FormatInt(int64(data), 10)
+	case int32:
+		if v.Type == RuneType {
+			return string(data)
+		}
+		return strconv.FormatInt(int64(data), 10)
+	case int64:
+		return strconv.FormatInt(data, 10)
+	case uint:
+		return strconv.FormatUint(uint64(data), 10)
+	case uint8:
+		if v.Type == ByteType {
+			return string([]byte{data})
+		}
+		return strconv.FormatUint(uint64(data), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(data), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(data), 10)
+	case uint64:
+		return strconv.FormatUint(data, 10)
+	case float32:
+		return strconv.FormatFloat(float64(data), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(data, 'g', -1, 64)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(data)
+	case time.Time:
+		switch v.Type {
+		case DateType:
+			return data.Format(dateLayout)
+		case TimeType:
+			return data.Format(timeLayout)
+		default:
+			return data.Format(datetimeLayout)
+		}
+	case Decimal:
+		return data.String()
+	case Money:
+		return data.String()
+	default:
+		return fmt.Sprint(data)
+	}
+}
+
+// encodeValue turns a Value into what is written to the item_attribute value column
+func encodeValue(v Value) (any, error) {
+	if v.Data == nil {
+		return nil, nil
+	}
+
+	switch v.Type.Base() {
+	case BoolType:
+		if b, ok := v.Data.(bool); ok {
+			return b, nil
+		}
+	case StringType:
+		if s, ok := v.Data.(string); ok {
+			return s, nil
+		}
+	case IntType, Int8Type, Int16Type, Int32Type, Int64Type, RuneType,
+		UintType, Uint8Type, Uint16Type, Uint32Type, Uint64Type, ByteType:
+		return encodeInteger(v)
+	case Float32Type:
+		if f, ok := v.Data.(float32); ok {
+			return float64(f), nil
+		}
+	case Float64Type:
+		if f, ok := v.Data.(float64); ok {
+			return f, nil
+		}
+	case BlobType:
+		if b, ok := v.Data.([]byte); ok {
+			return b, nil
+		}
+	case DateType, TimeType, DatetimeType:
+		if t, ok := v.Data.(time.Time); ok {
+			return encodeTime(v.Type, t), nil
+		}
+	case DecimalType:
+		if d, ok := v.Data.(Decimal); ok {
+			return encodeDecimal(v.Type, d)
+		}
+	case MoneyType:
+		if m, ok := v.Data.(Money); ok {
+			return encodeMoney(v.Type, m)
+		}
+	default:
+		return nil, fmt.Errorf("'%s' is not a valid attribute type", v.Type)
+	}
+
+	return nil, fmt.Errorf("%T can not be stored as %s", v.Data, v.Type)
+}
+
+// Ranges of the integer types, the sign of a value is checked before its magnitude is compared to them
+var integerRanges = map[AttributeType]struct{ min int64; max uint64 }{
+	IntType: {math.MinInt, math.MaxInt},
+	Int8Type: {math.MinInt8, math.MaxInt8},
+	Int16Type: {math.MinInt16, math.MaxInt16},
+	Int32Type: {math.MinInt32, math.MaxInt32},
+	Int64Type: {math.MinInt64, math.MaxInt64},
+	RuneType: {math.MinInt32, math.MaxInt32},
+	UintType: {0, math.MaxUint},
+	Uint8Type: {0, math.MaxUint8},
+	Uint16Type: {0, math.MaxUint16},
+	Uint32Type: {0, math.MaxUint32},
+	Uint64Type: {0, math.MaxUint64},
+	ByteType: {0, math.MaxUint8},
+}
+
+// uint and uint64 values are stored as text, sqlite can not hold them as integers above math.MaxInt64.
+// The digits are padded to a fixed width so they sort and compare as text in the order of the numbers they hold,
+// and prefixed so the numeric affinity of the value column does not turn them back into numbers
+const (
+	storedUintPrefix = "u"
+	storedUintWidth = 20
+)
+
+// isStoredAsText reports whether values of integer type t are stored as padded text instead of an integer
+func isStoredAsText(t AttributeType) bool {
+	return t == UintType || t == Uint64Type
+}
+
+// integerOf returns the magnitude and sign of data, ok is false when data is not a Go integer
+func integerOf(data any) (magnitude uint64, negative bool, ok bool) {
+	var i int64
+	switch d := data.(type) {
+	case int:
+		i = int64(d)
+	case int8:
+		i = int64(d)
+	case int16:
+		i = int64(d)
+	case int32:
+		i = int64(d)
+	case int64:
+		i = d
+	case uint:
+		return uint64(d), false, true
+	case uint8:
+		return uint64(d), false, true
+	case uint16:
+		return uint64(d), false, true
+	case uint32:
+		return uint64(d), false, true
+	case uint64:
+		return d, false, true
+	default:
+		return 0, false, false
+	}
+
+	if i < 0 {
+		// -i overflows for math.MinInt64, the conversion to uint64 wraps it back to its magnitude
+		return uint64(-i), true, true
+	}
+
+	return uint64(i), false, true
+}
+
+// encodeInteger stores any Go integer as integer type v.Type, as long as it is in range of the type
+func encodeInteger(v Value) (any, error) {
+	magnitude, negative, ok := integerOf(v.Data)
+	if !ok {
+		return nil, fmt.Errorf("%T can not be stored as %s", v.Data, v.Type)
+	}
+
+	r := integerRanges[v.Type]
+	if negative && (r.min == 0 || magnitude > uint64(-(r.min + 1)) + 1) || !negative && magnitude > r.max {
+		return nil, fmt.Errorf("%v is out of range for %s", v.Data, v.Type)
+	}
+
+	if isStoredAsText(v.Type) {
+		return fmt.Sprintf("%s%0*d", storedUintPrefix, storedUintWidth, magnitude), nil
+	}
+
+	if negative {
+		return -int64(magnitude), nil
+	}
+
+	return int64(magnitude), nil
+}
+
+// decodeValue turns a raw value read from the item_attribute value column back into a Value of type t
+func decodeValue(t AttributeType, raw any) (Value, error) {
+	v := Value{Type: t}
+	if raw == nil {
+		return v, nil
+	}
+
+	switch t.Base() {
+	case BoolType:
+		i, err := rawInt(raw, t)
+		if err != nil {
+			return v, err
+		}
+		v.Data = i != 0
+	case StringType:
+		switch s := raw.(type) {
+		case string:
+			v.Data = s
+		case []byte:
+			v.Data = string(s)
+		default:
+			v.Data = fmt.Sprint(s)
+		}
+	case IntType:
+		i, err := rawIntRange(raw, t, math.MinInt, math.MaxInt)
+		if err != nil {
+			return v, err
+		}
+		v.Data = int(i)
+	case Int8Type:
+		i, err := rawIntRange(raw, t, math.MinInt8, math.MaxInt8)
+		if err != nil {
+			return v, err
+		}
+		v.Data = int8(i)
+	case Int16Type:
+		i, err := rawIntRange(raw, t, math.MinInt16, math.MaxInt16)
+		if err != nil {
+			return v, err
+		}
+		v.Data = int16(i)
+	case Int32Type:
+		i, err := rawIntRange(raw, t, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return v, err
+		}
+		v.Data = int32(i)
+	case Int64Type:
+		i, err := rawInt(raw, t)
+		if err != nil {
+			return v, err
+		}
+		v.Data = i
+	case UintType:
+		i, err := rawUint(raw, t, math.MaxUint)
+		if err != nil {
+			return v, err
+		}
+		v.Data = uint(i)
+	case Uint8Type, ByteType:
+		i, err := rawIntRange(raw, t, 0, math.MaxUint8)
+		if err != nil {
+			return v, err
+		}
+		v.Data = uint8(i)
+	case Uint16Type:
+		i, err := rawIntRange(raw, t, 0, math.MaxUint16)
+		if err != nil {
+			return v, err
+		}
+		v.Data = uint16(i)
+	case Uint32Type:
+		i, err := rawIntRange(raw, t, 0, math.MaxUint32)
+		if err != nil {
+			return v, err
+		}
+		v.Data = uint32(i)
+	case Uint64Type:
+		i, err := rawUint(raw, t, math.MaxUint64)
+		if err != nil {
+			return v, err
+		}
+		v.Data = i
+	case RuneType:
+		i, err := rawIntRange(raw, t, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return v, err
+		}
+		v.Data = rune(i)
+	case Float32Type:
+		f, err := rawFloat(raw, t)
+		if err != nil {
+			return v, err
+		}
+		v.Data = float32(f)
+	case Float64Type:
+		f, err := rawFloat(raw, t)
+		if err != nil {
+			return v, err
+		}
+		v.Data = f
+	case BlobType:
+		switch b := raw.(type) {
+		case []byte:
+			v.Data = b
+		case string:
+			v.Data = []byte(b)
+		default:
+			return v, fmt.Errorf("stored %T can not be read as %s", raw, t)
+		}
+	case DateType, TimeType, DatetimeType:
+		switch s := raw.(type) {
+		case time.Time:
+			v.Data = canonicalTime(t, s)
+		case string:
+			parsed, err := decodeTime(t, s)
+			if err != nil {
+				return v, err
+			}
+			v.Data = parsed
+		default:
+			return v, fmt.Errorf("stored %T can not be read as %s", raw, t)
+		}
+	case DecimalType:
+		_, scale, ok := t.DecimalParams()
+		if !ok {
+			return v, fmt.Errorf("'%s' is not a valid attribute type", t)
+		}
+
+		i, err := rawInt(raw, t)
+		if err != nil {
+			return v, err
+		}
+		v.Data = Decimal{i, scale}
+	case MoneyType:
+		s, ok := raw.(string)
+		if !ok {
+			return v, fmt.Errorf("stored %T can not be read as %s", raw, t)
+		}
+
+		m, err := decodeMoney(t, s)
+		if err != nil {
+			return v, err
+		}
+		v.Data = m
+	default:
+		return v, fmt.Errorf("'%s' is not a valid attribute type", t)
+	}
+
+	return v, nil
+}
+
+func rawInt(raw any, t AttributeType) (int64, error) {
+	switch i := raw.(type) {
+	case int64:
+		return i, nil
+	case bool:
+		if i {
+			return 1, nil
+		}
+		return 0, nil
+	case float64:
+		if i != math.Trunc(i) {
+			return 0, fmt.Errorf("stored %v can not be read as %s", i, t)
+		}
+		return int64(i), nil
+	default:
+		return 0, fmt.Errorf("stored %T can not be read as %s", raw, t)
+	}
+}
+
+func rawIntRange(raw any, t AttributeType, min int64, max int64) (int64, error) {
+	i, err := rawInt(raw, t)
+	if err != nil {
+		return 0, err
+	}
+
+	if i < min || i > max {
+		return 0, fmt.Errorf("stored %v is out of range for %s", i, t)
+	}
+
+	return i, nil
+}
+
+// rawUint reads a value stored as padded text, or as an integer like it was before uint and uint64 were stored as text
+func rawUint(raw any, t AttributeType, max uint64) (uint64, error) {
+	var s string
+	switch r := raw.(type) {
+	case string:
+		s = r
+	case []byte:
+		s = string(r)
+	default:
+		i, err := rawIntRange(raw, t, 0, math.MaxInt64)
+		return uint64(i), err
+	}
+
+	digits, ok := strings.CutPrefix(s, storedUintPrefix)
+	if !ok {
+		return 0, fmt.Errorf("stored '%s' can not be read as %s", s, t)
+	}
+
+	i, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("stored '%s' can not be read as %s", s, t)
+	}
+
+	if i > max {
+		return 0, fmt.Errorf("stored %v is out of range for %s", i, t)
+	}
+
+	return i, nil
+}
+
+func rawFloat(raw any, t AttributeType) (float64, error) {
+	switch f := raw.(type) {
+	case float64:
+		return f, nil
+	case int64:
+		return float64(f), nil
+	default:
+		return 0, fmt.Errorf("stored %T can not be read as %s", raw, t)
+	}
+}
@@ -0,0 +1,192 @@
+package geaves
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Every view geaves creates is named with the prefix of its tenant followed by the entity slug
+const entityViewPrefix = "geaves_"
+
+// viewPrefix is entityViewPrefix for the default tenant, other tenants have their id before the underscore
+func (q *Queries) viewPrefix() string {
+	if q.tenant == DefaultTenantID {
+		return entityViewPrefix
+	}
+
+	return fmt.Sprintf("geaves%d_", q.tenant)
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// viewCast is the sqlite expression reading a stored value of type t as a plain column
+func viewCast(t AttributeType, expr string) string {
+	_, scale, _ := t.DecimalParams()
+
+	switch t.Base() {
+	case BoolType, IntType, Int8Type, Int16Type, Int32Type, Int64Type,
+		Uint8Type, Uint16Type, Uint32Type, ByteType, RuneType:
+		return fmt.Sprintf("CAST(%s AS INTEGER)", expr)
+	case UintType, Uint64Type:
+		// Stored as prefixed fixed width text (see encodeInteger), read as an integer up to math.MaxInt64 and as text
+		// without its padding above it, where an integer would be capped and a real would lose digits.
+		// Values stored as integers before are kept as they are
+		maxInt := fmt.Sprintf("%0*d", storedUintWidth, math.MaxInt64)
+		return fmt.Sprintf("CASE typeof(%[1]s) WHEN 'text' THEN CASE WHEN substr(%[1]s, 2) <= '%[2]s' THEN CAST(substr(%[1]s, 2) AS INTEGER) ELSE ltrim(substr(%[1]s, 2), '0') END ELSE %[1]s END",
+			expr, maxInt)
+	case Float32Type, Float64Type:
+		return fmt.Sprintf("CAST(%s AS REAL)", expr)
+	case StringType, DateType, TimeType, DatetimeType:
+		return fmt.Sprintf("CAST(%s AS TEXT)", expr)
+	case DecimalType:
+		// Stored unscaled, shown like FormatValue writes it
+		return decimalText(fmt.Sprintf("CAST(%s AS INTEGER)", expr), scale)
+	case MoneyType:
+		// Stored as its currency followed by its offset amount (see encodeMoney), shown like FormatValue writes it
+		amount := fmt.Sprintf("(CAST(substr(%s, 4) AS INTEGER) - %d)", expr, storedMoneyOffset)
+		return fmt.Sprintf("(%s || ' ' || substr(%s, 1, 3))", decimalText(amount, scale), expr)
+	default:
+		return expr
+	}
+}
+
+// decimalText is the sqlite expression writing the unscaled integer unscaled at scale as text like Decimal.String,
+// with integer arithmetic only so no digit is lost to a real
+func decimalText(unscaled string, scale int) string {
+	if scale <= 0 {
+		return fmt.Sprintf("CAST(%s AS TEXT)", unscaled)
+	}
+
+	// printf writes a missing value as 0, so it is checked for first
+	return fmt.Sprintf("IIF(%[2]s IS NULL, NULL, printf('%%s%%d.%%0%[1]dd', IIF(%[2]s < 0, '-', ''), abs(%[2]s) / %[3]d, abs(%[2]s) %% %[3]d))",
+		scale, unscaled, int64(math.Pow10(scale)))
+}
+
+func entityView(prefix string, entity Entity, attributes []EntityAttributeEmbed) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("CREATE VIEW %s AS\nSELECT\n  items.id AS item_id", quoteIdent(prefix + entity.Slug)))
+	for _, attribute := range attributes {
+		value := fmt.Sprintf("(SELECT value FROM item_attribute WHERE item_attribute.item_id = items.id AND item_attribute.attribute_id = %d)", attribute.ID)
+		sb.WriteString(fmt.Sprintf(",\n  %s AS %s", viewCast(attribute.Type, value), quoteIdent(attribute.Slug)))
+	}
+	sb.WriteString(fmt.Sprintf("\nFROM items\nWHERE items.entity_id = %d;\n", entity.ID))
+
+	return sb.String()
+}
+
+// entityViewStatements returns a drop and a create statement per entity
+func entityViewStatements(ctx context.Context, q *Queries) ([]string, error) {
+	entities, err := q.ListEntities(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list entities: %w", err)
+	}
+
+	var stmts []string
+	for _, entity := range entities {
+		attributes, err := entity.GetAttributes(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get attributes of %s: %w", entity.Slug, err)
+		}
+
+		stmts = append(stmts,
+			fmt.Sprintf("DROP VIEW IF EXISTS %s;\n", quoteIdent(q.viewPrefix() + entity.Slug)),
+			entityView(q.viewPrefix(), entity, attributes),
+		)
+	}
+
+	return stmts, nil
+}
+
+// GenerateEntityViews returns sql creating one view per entity of the tenant of q named geaves_<entity slug>,
+// or geaves<tenant id>_<entity slug> outside the default tenant, each view has an item_id column followed by a column per linked attribute named by the attribute slug
+func GenerateEntityViews(ctx context.Context, q *Queries) (string, error) {
+	stmts, err := entityViewStatements(ctx, q)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(stmts, "\n"), nil
+}
+
+const listEntityViews = `
+SELECT name FROM sqlite_master WHERE type = 'view' AND name LIKE ? ESCAPE '\';
+`
+
+// ListEntityViews lists the entity views of the tenant of q
+func (q *Queries) ListEntityViews(ctx context.Context) ([]string, error) {
+	rows, err := q.queryContext(ctx, listEntityViews, strings.TrimSuffix(q.viewPrefix(), "_") + `\_%`)
+	if err != nil {
+		return nil, classify(err)
+	}
+	defer rows.Close()
+
+	var items []string
+	for rows.Next() {
+		var i string
+		if err := rows.Scan(&i); err != nil {
+			return items, classify(err)
+		}
+
+		items = append(items, i)
+	}
+
+	return items, classify(rows.Err())
+}
+
+// CreateEntityViews drops every existing entity view of the tenant of q and creates them again from the current entities and links
+func CreateEntityViews(ctx context.Context, q *Queries) error {
+	views, err := q.ListEntityViews(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to list existing views: %w", err)
+	}
+
+	for _, view := range views {
+		if _, err := q.execContext(ctx, fmt.Sprintf("DROP VIEW %s;", quoteIdent(view))); err != nil {
+			return fmt.Errorf("Failed to drop view %s: %w", view, err)
+		}
+	}
+
+	stmts, err := entityViewStatements(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		if _, err := q.execContext(ctx, stmt); err != nil {
+			return fmt.Errorf("Failed to create view: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RefreshEntityViews recreates the entity views if they have been created before, and does nothing otherwise.
+// Creating, renaming, retyping and deleting entities, attributes and links through Queries already refreshes them,
+// it is only needed after changing the tables some other way
+func RefreshEntityViews(ctx context.Context, q *Queries) error {
+	views, err := q.ListEntityViews(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to list existing views: %w", err)
+	}
+
+	if len(views) == 0 {
+		return nil
+	}
+
+	return CreateEntityViews(ctx, q)
+}
+
+// refreshViews runs RefreshEntityViews after a change to entities, attributes or links, so the entity views,
+// once created, always match them
+func (q *Queries) refreshViews(ctx context.Context) error {
+	if err := RefreshEntityViews(ctx, q); err != nil {
+		return fmt.Errorf("Failed to refresh entity views: %w", err)
+	}
+
+	return nil
+}